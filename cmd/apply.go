@@ -0,0 +1,412 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/manifest"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Create or update deployments from a manifest",
+	Long: `Create or update deployments from a coderun.yaml manifest.
+
+Each app is created if no deployment with its name exists, and updated otherwise.
+
+Example manifest:
+  version: 1
+  app:
+    name: web-app
+    image: my-app:v1.2.0     # or build: {context: ., dockerfile: Dockerfile}
+    replicas: 2
+    cpu_limit: 500m
+    memory_limit: 512Mi
+    http_port: 8080
+    env_file: production.env
+    env:
+      LOG_LEVEL: info
+    env_groups: [observability]   # attached in order; apps without it keep their groups
+
+A manifest can also declare several apps under "apps". Apps listed in
+depends_on are deployed first and must be ready before their dependents
+start; independent apps are deployed concurrently.
+
+  version: 1
+  apps:
+    - name: db
+      image: postgres:16
+      tcp_port: 5432
+    - name: api
+      image: my-api:v2
+      http_port: 8080
+      depends_on: [db]
+
+Environment overlays and variables:
+  --overlay staging loads coderun.staging.yaml next to the base manifest and merges
+  it on top: mappings are merged, scalars and plain lists override, apps are
+  matched by name, and a null value removes a key.
+
+  ${VAR} and ${VAR:-default} in values are replaced with --var values first,
+  then with the process environment. Use $$ for a literal $.
+
+Examples:
+  coderun apply
+  coderun apply -f deploy/coderun.yaml
+  coderun apply -f stack.yaml --parallel 2 --timeout 15m
+  coderun apply --overlay staging --var IMAGE_TAG=v1.4.2
+  coderun apply --overlay prod --render`,
+	Args: cobra.NoArgs,
+	Run:  runApply,
+}
+
+var (
+	applyFile     string
+	applyParallel int
+	applyTimeout  time.Duration
+	applyOverlay  string
+	applyVars     []string
+	applyRender   bool
+)
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	applyCmd.Flags().StringVarP(&applyFile, "file", "f", manifest.DefaultFile, "Path to the manifest file")
+	applyCmd.Flags().IntVar(&applyParallel, "parallel", 4, "Maximum number of apps deployed at the same time")
+	applyCmd.Flags().DurationVar(&applyTimeout, "timeout", 10*time.Minute, "How long to wait for an app to be ready before deploying its dependents")
+	applyCmd.Flags().StringVar(&applyOverlay, "overlay", "", "Environment overlay to merge (e.g. staging loads coderun.staging.yaml)")
+	applyCmd.Flags().StringArrayVar(&applyVars, "var", nil, "Variable for ${VAR} interpolation as KEY=VALUE (repeatable)")
+	applyCmd.Flags().BoolVar(&applyRender, "render", false, "Print the rendered manifest and exit without deploying")
+}
+
+// manifestOptions builds manifest load options from the --overlay and --var flags, exiting on bad input
+func manifestOptions(file, overlay string, vars []string) manifest.Options {
+	opts := manifest.Options{Vars: make(map[string]string)}
+
+	if overlay != "" {
+		overlayPath := manifest.OverlayPath(file, overlay)
+		if _, err := os.Stat(overlayPath); err != nil {
+			fmt.Printf("Overlay for environment '%s' not found: %s\n", overlay, overlayPath)
+			os.Exit(1)
+		}
+		opts.Overlays = append(opts.Overlays, overlayPath)
+	}
+
+	for _, v := range vars {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			fmt.Printf("Invalid --var '%s' (expected KEY=VALUE)\n", v)
+			os.Exit(1)
+		}
+		opts.Vars[key] = value
+	}
+
+	return opts
+}
+
+// applyResult is the outcome of applying one app
+type applyResult struct {
+	App        string
+	Action     string
+	Deployment *client.DeploymentResponse
+	Err        error
+	Duration   time.Duration
+}
+
+func runApply(cmd *cobra.Command, args []string) {
+	if applyParallel < 1 {
+		fmt.Println("--parallel must be at least 1")
+		os.Exit(1)
+	}
+
+	opts := manifestOptions(applyFile, applyOverlay, applyVars)
+
+	if applyRender {
+		rendered, err := manifest.Render(applyFile, opts)
+		if err != nil {
+			fmt.Printf("Invalid manifest:\n%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(string(rendered))
+
+		// Still report validation problems in the rendered result
+		if _, err := manifest.Load(applyFile, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid manifest:\n%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	m, err := manifest.Load(applyFile, opts)
+	if err != nil {
+		fmt.Printf("Invalid manifest:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	// Create client
+	apiClient, _ := newAPIClient()
+
+	apps := m.AllApps()
+	if len(apps) == 1 {
+		result := applyApp(apiClient, m, apps[0], newAppLogger(""), false)
+		if result.Err != nil {
+			exitWithError("Deployment failed", result.Err)
+		}
+		fmt.Printf("✅ Deployment %s successfully!\n", result.Action)
+		printDeployment(result.Deployment)
+		return
+	}
+
+	fmt.Printf("Applying %d apps from %s...\n", len(apps), applyFile)
+	results := rolloutApps(m, applyParallel, func(app *manifest.App, waitReady bool) applyResult {
+		return applyApp(apiClient, m, app, newAppLogger(app.Name), waitReady)
+	})
+
+	// Print per-app summary
+	fmt.Println()
+	failed := 0
+	rows := make([][]string, 0, len(apps))
+	for _, app := range apps {
+		result := results[app.Name]
+		detail := ""
+		if result.Err != nil {
+			failed++
+			detail = result.Err.Error()
+		} else if result.Deployment != nil {
+			detail = getConnectionString(result.Deployment)
+		}
+		duration := "-"
+		if result.Duration > 0 {
+			duration = result.Duration.Round(time.Second).String()
+		}
+		rows = append(rows, []string{app.Name, result.Action, duration, detail})
+	}
+	printTable([]string{"App", "Result", "Duration", "Details"}, rows)
+
+	if failed > 0 {
+		for _, result := range results {
+			exitIfSessionExpired(result.Err)
+		}
+		fmt.Printf("\n%d of %d apps failed\n", failed, len(apps))
+		os.Exit(1)
+	}
+	fmt.Printf("\n✅ All %d apps applied successfully!\n", len(apps))
+}
+
+// rolloutApps applies all apps of a manifest in dependency order with a bounded worker pool.
+// An app starts once all of its dependencies are deployed and ready; when a dependency fails,
+// its dependents are skipped. apply deploys one app; waitReady is set when other apps depend on it.
+func rolloutApps(m *manifest.Manifest, parallel int, apply func(app *manifest.App, waitReady bool) applyResult) map[string]*applyResult {
+	apps := m.AllApps()
+	dependents := m.Dependents()
+
+	byName := make(map[string]*manifest.App, len(apps))
+	remaining := make(map[string]int, len(apps))
+	for _, app := range apps {
+		byName[app.Name] = app
+		remaining[app.Name] = len(app.DependsOn)
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]*applyResult, len(apps))
+		queue   = make(chan *manifest.App, len(apps))
+	)
+	wg.Add(len(apps))
+
+	// finish releases or skips the dependents of a finished app; mu must be held
+	var finish func(name string, ok bool)
+	finish = func(name string, ok bool) {
+		for _, dependent := range dependents[name] {
+			if results[dependent] != nil {
+				continue
+			}
+			if !ok {
+				results[dependent] = &applyResult{
+					App:    dependent,
+					Action: "skipped",
+					Err:    fmt.Errorf("dependency %s failed", name),
+				}
+				wg.Done()
+				finish(dependent, false)
+				continue
+			}
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				queue <- byName[dependent]
+			}
+		}
+	}
+
+	for _, app := range apps {
+		if remaining[app.Name] == 0 {
+			queue <- app
+		}
+	}
+
+	for i := 0; i < parallel && i < len(apps); i++ {
+		go func() {
+			for app := range queue {
+				waitReady := len(dependents[app.Name]) > 0
+				result := apply(app, waitReady)
+
+				mu.Lock()
+				results[app.Name] = &result
+				finish(app.Name, result.Err == nil)
+				mu.Unlock()
+				wg.Done()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(queue)
+
+	return results
+}
+
+// applyApp builds (when needed), creates or updates a single app, and optionally waits until it is ready
+func applyApp(apiClient *client.Client, m *manifest.Manifest, app *manifest.App, log *appLogger, waitReady bool) applyResult {
+	started := time.Now()
+	result := applyResult{App: app.Name, Action: "failed"}
+	fail := func(err error) applyResult {
+		result.Err = err
+		result.Duration = time.Since(started)
+		return result
+	}
+
+	deployReq, err := app.DeploymentCreate(m.Dir)
+	if err != nil {
+		return fail(fmt.Errorf("error preparing deployment: %w", err))
+	}
+	for _, key := range plainSecretRefs(deployReq.EnvironmentVars) {
+		log.Printf("Warning: %s holds a secret reference but is a plain variable, so it is not resolved; move it to secrets\n", key)
+	}
+	if err := resolveSecretRefs(log, deployReq.SecretVars); err != nil {
+		return fail(fmt.Errorf("error resolving secret references: %w", err))
+	}
+
+	log.Printf("Looking up deployment for app '%s'...\n", app.Name)
+	existing, err := apiClient.FindDeploymentByName(app.Name)
+	if err != nil && !errors.Is(err, client.ErrDeploymentNotFound) {
+		return fail(fmt.Errorf("failed to fetch deployments: %w", err))
+	}
+
+	if app.EnvSchema != nil {
+		// The app uses the env groups of the manifest, or keeps those it has, and keeps its
+		// secrets unless the manifest sets them
+		groups := app.EnvGroups
+		if groups == nil && existing != nil {
+			groups = existing.EnvGroups
+		}
+		kept, deferred, err := keptEnv(apiClient, existing, groups, deployReq.SecretVars == nil)
+		if err != nil {
+			return fail(err)
+		}
+		if err := checkEnvSchema(app.EnvSchema, deferred, kept, deployReq.EnvironmentVars, deployReq.SecretVars); err != nil {
+			return fail(fmt.Errorf("environment does not match env_schema:\n%w", err))
+		}
+	}
+	for _, key := range utils.SecretLookingKeys(deployReq.EnvironmentVars) {
+		log.Printf("Warning: %s looks like a secret but is a plain variable; move it to secrets to keep it out of output\n", key)
+	}
+
+	// Handle build from source
+	if app.Build != nil {
+		deployReq.Image, err = buildFromSource(apiClient, log, app.Name, app.BuildContext(m.Dir), app.Dockerfile())
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	if existing == nil {
+		log.Printf("Creating %s with image %s...\n", app.Name, deployReq.Image)
+		result.Deployment, err = apiClient.CreateDeployment(deployReq)
+		result.Action = "created"
+	} else {
+		log.Printf("Updating %s (%s) with image %s...\n", app.Name, existing.ID, deployReq.Image)
+		result.Deployment, err = apiClient.UpdateDeployment(existing.ID, deployReq)
+		result.Action = "updated"
+	}
+	if err != nil {
+		result.Action = "failed"
+		if errors.Is(err, client.ErrSessionExpired) {
+			return fail(err)
+		}
+		return fail(errors.New(parseValidationError(err.Error())))
+	}
+	recordRevision(result.Deployment, result.Action)
+
+	if app.EnvGroups != nil {
+		result.Deployment, err = syncEnvGroups(apiClient, log, result.Deployment, app.EnvGroups)
+		if err != nil {
+			result.Action = "failed"
+			return fail(err)
+		}
+	}
+
+	if waitReady {
+		log.Printf("Waiting for %s to be ready...\n", app.Name)
+		if err := waitForReplicas(apiClient, result.Deployment.ID, applyTimeout); err != nil {
+			result.Action = "not ready"
+			return fail(err)
+		}
+		log.Printf("✅ %s is ready\n", app.Name)
+	}
+
+	result.Duration = time.Since(started)
+	return result
+}
+
+// waitForReplicas polls the deployment status until all desired replicas are ready
+func waitForReplicas(apiClient *client.Client, deploymentID string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	status, err := apiClient.WaitForDeploymentReady(ctx, deploymentID, client.DeploymentWaitOptions{})
+	var timeoutErr *client.WaitTimeoutError
+	if errors.As(err, &timeoutErr) && status != nil {
+		return fmt.Errorf("timed out after %s waiting for replicas (%d/%d ready)", timeout, status.ReplicasReady, status.ReplicasDesired)
+	}
+	return err
+}
+
+// syncEnvGroups attaches and detaches env groups so that a deployment uses exactly the desired
+// groups, in order. Attaching always appends, so the groups after the first difference are
+// detached and attached again.
+func syncEnvGroups(apiClient *client.Client, log *appLogger, deployment *client.DeploymentResponse, desired []string) (*client.DeploymentResponse, error) {
+	live := deployment.EnvGroups
+	common := 0
+	for common < len(live) && common < len(desired) && live[common] == desired[common] {
+		common++
+	}
+
+	for _, name := range live[common:] {
+		updated, err := apiClient.DetachEnvGroup(deployment.ID, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detach env group %s: %w", name, err)
+		}
+		log.Printf("Detached env group %s\n", name)
+		deployment = updated
+	}
+	for _, name := range desired[common:] {
+		updated, err := apiClient.AttachEnvGroup(deployment.ID, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach env group %s: %w", name, err)
+		}
+		log.Printf("Attached env group %s\n", name)
+		deployment = updated
+	}
+	return deployment, nil
+}
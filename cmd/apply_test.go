@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/helmcode/coderun-cli/internal/manifest"
+)
+
+// rolloutRecorder is a fake apply function that records start order and concurrency
+type rolloutRecorder struct {
+	mu        sync.Mutex
+	running   int
+	peak      int
+	started   []string
+	finished  map[string]bool
+	waitReady map[string]bool
+	fail      map[string]bool
+	// violations lists apps that started before one of their dependencies finished
+	violations []string
+}
+
+func (r *rolloutRecorder) apply(app *manifest.App, waitReady bool) applyResult {
+	r.mu.Lock()
+	r.running++
+	r.peak = max(r.peak, r.running)
+	r.started = append(r.started, app.Name)
+	r.waitReady[app.Name] = waitReady
+	for _, dep := range app.DependsOn {
+		if !r.finished[dep] {
+			r.violations = append(r.violations, app.Name)
+		}
+	}
+	r.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	r.mu.Lock()
+	r.running--
+	r.finished[app.Name] = true
+	r.mu.Unlock()
+
+	if r.fail[app.Name] {
+		return applyResult{App: app.Name, Action: "failed", Err: errors.New("boom")}
+	}
+	return applyResult{App: app.Name, Action: "created"}
+}
+
+func TestRolloutApps(t *testing.T) {
+	independent := `version: 1
+apps:
+  - {name: one, image: app:1}
+  - {name: two, image: app:1}
+  - {name: three, image: app:1}
+  - {name: four, image: app:1}
+  - {name: five, image: app:1}
+  - {name: six, image: app:1}
+`
+	stack := `version: 1
+apps:
+  - {name: web, image: web:1, depends_on: [api, cache]}
+  - {name: api, image: api:1, depends_on: [database]}
+  - {name: cache, image: redis:7}
+  - {name: database, image: postgres:16}
+  - {name: worker, image: worker:1, depends_on: [database]}
+  - {name: cron, image: cron:1}
+`
+
+	tests := []struct {
+		name        string
+		manifest    string
+		parallel    int
+		fail        []string
+		wantPeak    int
+		wantActions map[string]string
+		wantReady   []string
+	}{
+		{
+			name:     "independent apps are bounded by parallel",
+			manifest: independent,
+			parallel: 2,
+			wantPeak: 2,
+		},
+		{
+			name:     "parallel 1 is sequential",
+			manifest: independent,
+			parallel: 1,
+			wantPeak: 1,
+		},
+		{
+			name:      "dependencies run first",
+			manifest:  stack,
+			parallel:  4,
+			wantReady: []string{"api", "cache", "database"},
+			wantActions: map[string]string{
+				"web": "created", "api": "created", "cache": "created",
+				"database": "created", "worker": "created", "cron": "created",
+			},
+		},
+		{
+			name:     "a failure skips its dependents only",
+			manifest: stack,
+			parallel: 3,
+			fail:     []string{"database"},
+			wantActions: map[string]string{
+				"database": "failed", "api": "skipped", "worker": "skipped", "web": "skipped",
+				"cache": "created", "cron": "created",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := manifest.Parse([]byte(tt.manifest), manifest.DefaultFile)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			r := &rolloutRecorder{
+				finished:  make(map[string]bool),
+				waitReady: make(map[string]bool),
+				fail:      make(map[string]bool),
+			}
+			for _, name := range tt.fail {
+				r.fail[name] = true
+			}
+
+			results := rolloutApps(m, tt.parallel, r.apply)
+
+			if len(results) != len(m.Apps) {
+				t.Fatalf("got %d results, want %d", len(results), len(m.Apps))
+			}
+			if len(r.violations) > 0 {
+				t.Errorf("apps started before their dependencies finished: %v", r.violations)
+			}
+			if r.peak > tt.parallel {
+				t.Errorf("%d apps ran at the same time, parallel is %d", r.peak, tt.parallel)
+			}
+			if tt.wantPeak > 0 && r.peak != tt.wantPeak {
+				t.Errorf("peak concurrency = %d, want %d", r.peak, tt.wantPeak)
+			}
+			for name, want := range tt.wantActions {
+				if got := results[name].Action; got != want {
+					t.Errorf("%s: action = %q, want %q", name, got, want)
+				}
+			}
+			for _, name := range tt.wantReady {
+				if !r.waitReady[name] {
+					t.Errorf("%s has dependents but was not waited for", name)
+				}
+			}
+			for _, name := range []string{"web", "worker", "cron"} {
+				if r.waitReady[name] {
+					t.Errorf("%s has no dependents but was waited for", name)
+				}
+			}
+		})
+	}
+}
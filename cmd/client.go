@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// exitCodeSessionExpired is the exit code used when the stored session can no longer be used
+const exitCodeSessionExpired = 3
+
+// tokenExpiryWarning is how long before expiry a warning is printed
+const tokenExpiryWarning = 15 * time.Minute
+
+// newAPIClient loads the config and returns an authenticated client, using CODERUN_TOKEN when set.
+// It exits when the user is not logged in or the session has already expired.
+func newAPIClient() (*client.Client, *utils.Config) {
+	// Load config
+	config, err := utils.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// CODERUN_TOKEN takes precedence over the stored login, e.g. for CI jobs
+	if token := utils.GetEnvToken(); token != "" {
+		apiClient := client.NewClient(config.BaseURL)
+		apiClient.SetToken(token)
+		return apiClient, config
+	}
+
+	if config.AccessToken == "" {
+		fmt.Println("Please login first using 'coderun login'")
+		os.Exit(1)
+	}
+
+	// Warn before the token runs out, or stop early if it cannot be refreshed
+	if expiry, ok := utils.TokenExpiry(config.AccessToken); ok && config.RefreshToken == "" {
+		remaining := time.Until(expiry)
+		if remaining <= 0 {
+			exitSessionExpired()
+		}
+		if remaining < tokenExpiryWarning {
+			fmt.Fprintf(os.Stderr, "⚠️  Your session expires in %s. Run 'coderun login' to renew it.\n", remaining.Round(time.Second))
+		}
+	}
+
+	apiClient := client.NewClient(config.BaseURL)
+	apiClient.SetToken(config.AccessToken)
+	apiClient.SetRefreshToken(config.RefreshToken)
+
+	// Persist refreshed tokens so the next command starts with a valid session
+	apiClient.OnTokenRefresh = func(loginResp *client.LoginResponse) {
+		config.AccessToken = loginResp.AccessToken
+		if loginResp.RefreshToken != "" {
+			config.RefreshToken = loginResp.RefreshToken
+		}
+		if err := utils.SaveConfig(config); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not save refreshed token: %v\n", err)
+		}
+	}
+
+	return apiClient, config
+}
+
+// exitWithError prints an error and exits, using a dedicated exit code for expired sessions
+func exitWithError(message string, err error) {
+	exitIfSessionExpired(err)
+	fmt.Printf("%s: %v\n", message, err)
+	os.Exit(1)
+}
+
+// exitIfSessionExpired exits with an actionable message when err is an expired session
+func exitIfSessionExpired(err error) {
+	if errors.Is(err, client.ErrSessionExpired) {
+		exitSessionExpired()
+	}
+}
+
+// exitSessionExpired exits when the token is rejected. Logging in again does not help when
+// CODERUN_TOKEN takes precedence over the stored login, so that case gets its own message.
+func exitSessionExpired() {
+	if utils.GetEnvToken() != "" {
+		fmt.Println("The token in CODERUN_TOKEN is invalid or expired, create a new one with 'coderun tokens create'")
+	} else {
+		fmt.Println("Session expired, run 'coderun login' to log in again")
+	}
+	os.Exit(exitCodeSessionExpired)
+}
@@ -1,13 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/helmcode/coderun-cli/internal/client"
-	"github.com/helmcode/coderun-cli/internal/utils"
 )
 
 // deleteCmd represents the delete command
@@ -34,56 +34,31 @@ func init() {
 func runDelete(cmd *cobra.Command, args []string) {
 	identifier := args[0]
 
-	// Load config
-	config, err := utils.LoadConfig()
-	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
-		os.Exit(1)
-	}
-
-	if config.AccessToken == "" {
-		fmt.Println("Please login first using 'coderun login'")
-		os.Exit(1)
-	}
-
 	// Create client
-	apiClient := client.NewClient(config.BaseURL)
-	apiClient.SetToken(config.AccessToken)
+	apiClient, _ := newAPIClient()
 
 	var deploymentID string
 
 	if byAppName {
 		// Look up deployment ID by app name
 		fmt.Printf("Looking up deployment for app '%s'...\n", identifier)
-		deploymentList, err := apiClient.ListDeployments()
-		if err != nil {
-			fmt.Printf("Failed to fetch deployments: %v\n", err)
-			os.Exit(1)
-		}
-
-		found := false
-		for _, deployment := range deploymentList.Deployments {
-			if deployment.AppName == identifier {
-				deploymentID = deployment.ID
-				found = true
-				break
-			}
-		}
-
-		if !found {
+		deployment, err := apiClient.FindDeploymentByName(identifier)
+		if errors.Is(err, client.ErrDeploymentNotFound) {
 			fmt.Printf("No deployment found with app name: %s\n", identifier)
 			os.Exit(1)
 		}
+		if err != nil {
+			exitWithError("Failed to fetch deployments", err)
+		}
+		deploymentID = deployment.ID
 	} else {
 		// Use identifier as deployment ID (could be full or partial)
 		deploymentID = identifier
 	}
 
 	fmt.Printf("Deleting deployment %s...\n", deploymentID)
-	err = apiClient.DeleteDeployment(deploymentID)
-	if err != nil {
-		fmt.Printf("Failed to delete deployment: %v\n", err)
-		os.Exit(1)
+	if err := apiClient.DeleteDeployment(deploymentID); err != nil {
+		exitWithError("Failed to delete deployment", err)
 	}
 
 	fmt.Printf("✅ Deployment %s deleted successfully!\n", deploymentID)
@@ -1,13 +1,17 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/secrets"
 	"github.com/helmcode/coderun-cli/internal/utils"
 	"github.com/spf13/cobra"
 )
@@ -23,11 +27,39 @@ Deploy from existing image:
   coderun deploy redis:latest --name my-redis --tcp-port 6379
   coderun deploy my-app:latest --name prod-app --replicas 2 --cpu 200m --memory 512Mi --http-port 3000 --env-file production.env
 
+Layered environment (later files win, --env wins over files):
+  coderun deploy my-app:latest --name prod-app --env-file base.env --env-file production.env --env LOG_LEVEL=debug --env API_KEY
+
+Secrets are sent separately from plain variables and never shown in output:
+  coderun deploy my-app:latest --name prod-app --env-file app.env --secret-file secrets.env --secret API_TOKEN
+
+Secret values can reference external secret stores; they are resolved locally at deploy time.
+Plain variables are never resolved, and a leading backslash keeps a secret literal:
+  DB_PASSWORD=vault://secret/data/app#db_password   (VAULT_ADDR and VAULT_TOKEN, KV v2)
+  TLS_KEY=file:///run/secrets/tls_key
+  SMTP_PASSWORD=cmd://pass show smtp
+  LITERAL_SECRET=\file:///not/a/reference
+
+Required variables, types and patterns declared in .env.schema (or --env-schema) are
+checked before deploying:
+  DATABASE_URL: {required: true, type: url}
+  LOG_LEVEL: {type: enum, values: [debug, info, warn, error]}
+
+Environment from JSON, YAML (e.g. a ConfigMap) or stdin:
+  coderun deploy my-app:latest --name prod-app --env-file config.yaml
+  vault kv get -format=json -field=data secret/app | coderun deploy my-app:latest --name prod-app --secret-file - --env-format json
+
 Build from source:
   coderun deploy --build . --name my-app
   coderun deploy --build ./my-app --name my-app --dockerfile Dockerfile.prod
   coderun deploy --build . --name web-app --http-port 8080 --env-file .env
 
+Update an existing app with the same name instead of failing:
+  coderun deploy my-app:v2 --name prod-app --http-port 3000 --upsert
+
+Wait until the app is ready and serving (fails with pod logs on a restart loop):
+  coderun deploy my-app:v2 --name prod-app --http-port 3000 --wait --timeout 5m
+
 With persistent storage (automatically forces replicas to 1):
   coderun deploy postgres:15 --name my-postgres --tcp-port 5432 --storage-size 5Gi --storage-path /var/lib/postgresql/data
   coderun deploy mysql:8 --name my-mysql --tcp-port 3306 --storage-size 10Gi --storage-path /var/lib/mysql
@@ -47,12 +79,22 @@ var (
 	replicas                  int
 	cpu                       string
 	memory                    string
+	cpuRequest                string
+	memoryRequest             string
 	httpPort                  int
 	tcpPort                   int
-	envFile                   string
+	envFiles                  []string
+	envOverrides              []string
+	envFormat                 string
+	secretFiles               []string
+	secretOverrides           []string
 	appName                   string
 	persistentVolumeSize      string
 	persistentVolumeMountPath string
+	upsert                    bool
+	envSchemaFile             string
+	deployWait                bool
+	deployTimeout             time.Duration
 	// Build flags
 	buildContext   string
 	dockerfilePath string
@@ -65,11 +107,22 @@ func init() {
 	deployCmd.Flags().IntVar(&replicas, "replicas", 1, "Number of replicas")
 	deployCmd.Flags().StringVar(&cpu, "cpu", "", "CPU resource limit (e.g., 100m, 0.5)")
 	deployCmd.Flags().StringVar(&memory, "memory", "", "Memory resource limit (e.g., 128Mi, 1Gi)")
+	deployCmd.Flags().StringVar(&cpuRequest, "cpu-request", "", "CPU guaranteed to the app (e.g., 50m); cannot exceed --cpu")
+	deployCmd.Flags().StringVar(&memoryRequest, "memory-request", "", "Memory guaranteed to the app (e.g., 64Mi); cannot exceed --memory")
 	deployCmd.Flags().IntVar(&httpPort, "http-port", 0, "HTTP port to expose")
 	deployCmd.Flags().IntVar(&tcpPort, "tcp-port", 0, "TCP port to expose")
-	deployCmd.Flags().StringVar(&envFile, "env-file", "", "Path to environment file")
+	deployCmd.Flags().StringArrayVar(&envFiles, "env-file", nil, "Path to environment file (repeatable, later files win)")
+	deployCmd.Flags().StringVar(&envFormat, "env-format", utils.EnvFormatAuto, "Format of --env-file: auto, dotenv, json or yaml ('-' reads the file from stdin)")
+	deployCmd.Flags().StringArrayVar(&envOverrides, "env", nil, "Environment variable as KEY=VALUE, or KEY to take it from the local environment (repeatable, wins over files)")
+	deployCmd.Flags().StringArrayVar(&secretFiles, "secret-file", nil, "Path to a file of secret variables, never shown in output (repeatable, same formats as --env-file)")
+	deployCmd.Flags().StringArrayVar(&secretOverrides, "secret", nil, "Secret variable as KEY=VALUE, or KEY to take it from the local environment (repeatable)")
 	deployCmd.Flags().StringVar(&appName, "name", "", "Application name (required, 3-30 chars, lowercase letters/numbers/hyphens only)")
 
+	deployCmd.Flags().StringVar(&envSchemaFile, "env-schema", "", "Env schema the merged env and secrets must match (default .env.schema when present)")
+	deployCmd.Flags().BoolVar(&upsert, "upsert", false, "Update the app in place if a deployment with the same name exists")
+	deployCmd.Flags().BoolVar(&deployWait, "wait", false, "Wait until replicas are ready, TLS is issued and the URL responds")
+	deployCmd.Flags().DurationVar(&deployTimeout, "timeout", 10*time.Minute, "Maximum time to wait with --wait")
+
 	// Persistent storage flags
 	deployCmd.Flags().StringVar(&persistentVolumeSize, "storage-size", "", "Size of persistent volume (e.g., '1Gi', '500Mi', '10Gi')")
 	deployCmd.Flags().StringVar(&persistentVolumeMountPath, "storage-path", "", "Path where to mount the volume (e.g., '/data', '/var/lib/mysql')")
@@ -163,28 +216,8 @@ func runDeploy(cmd *cobra.Command, args []string) {
 		image = args[0]
 	}
 
-	// Load config
-	config, err := utils.LoadConfig()
-	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
-		os.Exit(1)
-	}
-
-	if config.AccessToken == "" {
-		fmt.Println("Please login first using 'coderun login'")
-		os.Exit(1)
-	}
-
-	// Validate resource values
-	if err := utils.ValidateResourceValue(cpu, "cpu"); err != nil {
-		fmt.Printf("Invalid CPU value: %v\n", err)
-		os.Exit(1)
-	}
-
-	if err := utils.ValidateResourceValue(memory, "memory"); err != nil {
-		fmt.Printf("Invalid memory value: %v\n", err)
-		os.Exit(1)
-	}
+	// Create client
+	apiClient, _ := newAPIClient()
 
 	// Validate that only one of HTTP or TCP port is specified
 	if httpPort > 0 && tcpPort > 0 {
@@ -192,65 +225,40 @@ func runDeploy(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// Validate app name if provided
-	if appName != "" {
-		if len(appName) < 3 {
-			fmt.Println("App name must be at least 3 characters long")
-			os.Exit(1)
-		}
-		if len(appName) > 30 {
-			fmt.Println("App name must be no more than 30 characters long")
-			os.Exit(1)
-		}
-		// Validate format using regex: only lowercase letters, numbers, and hyphens
-		matched, _ := regexp.MatchString(`^[a-z0-9-]+$`, appName)
-		if !matched {
-			fmt.Println("App name must contain only lowercase letters, numbers, and hyphens")
-			os.Exit(1)
-		}
-		// Cannot start or end with hyphen
-		if strings.HasPrefix(appName, "-") || strings.HasSuffix(appName, "-") {
-			fmt.Println("App name cannot start or end with a hyphen")
-			os.Exit(1)
-		}
-	} else {
+	// Validate app name
+	if appName == "" {
 		fmt.Println("App name is required. Use --name to specify one (e.g., --name my-app)")
 		fmt.Println("App name must be 3-30 characters long and contain only lowercase letters, numbers, and hyphens")
 		os.Exit(1)
 	}
+	if err := utils.ValidateAppName(appName); err != nil {
+		fmt.Printf("Invalid app name: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Validate port ranges
-	if httpPort > 0 && (httpPort < 1 || httpPort > 65535) {
-		fmt.Println("HTTP port must be between 1 and 65535")
-		os.Exit(1)
+	if httpPort > 0 {
+		if err := utils.ValidatePort(httpPort, "HTTP"); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	}
-	if tcpPort > 0 && (tcpPort < 1 || tcpPort > 65535) {
-		fmt.Println("TCP port must be between 1 and 65535")
-		os.Exit(1)
+	if tcpPort > 0 {
+		if err := utils.ValidatePort(tcpPort, "TCP"); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	}
 
 	// Validate persistent storage flags
 	if persistentVolumeSize != "" || persistentVolumeMountPath != "" {
 		// Both flags must be provided together
-		if persistentVolumeSize == "" {
+		if persistentVolumeSize == "" || persistentVolumeMountPath == "" {
 			fmt.Println("When using persistent storage, both --storage-size and --storage-path are required")
 			os.Exit(1)
 		}
-		if persistentVolumeMountPath == "" {
-			fmt.Println("When using persistent storage, both --storage-size and --storage-path are required")
-			os.Exit(1)
-		}
-
-		// Validate storage size format
-		matched, _ := regexp.MatchString(`^\d+[MGT]i$`, persistentVolumeSize)
-		if !matched {
-			fmt.Println("Storage size must be in format like '1Gi', '500Mi', '10Gi'")
-			os.Exit(1)
-		}
-
-		// Validate mount path format (must be absolute path)
-		if !strings.HasPrefix(persistentVolumeMountPath, "/") {
-			fmt.Println("Storage path must be an absolute path starting with '/' (e.g., '/data', '/var/lib/mysql')")
+		if err := utils.ValidateStorage(persistentVolumeSize, persistentVolumeMountPath); err != nil {
+			fmt.Printf("Invalid storage: %v\n", err)
 			os.Exit(1)
 		}
 
@@ -261,139 +269,135 @@ func runDeploy(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	// Parse environment file if provided
-	var envVars map[string]string
-	if envFile != "" {
-		envVars, err = utils.ParseEnvFile(envFile)
-		if err != nil {
-			fmt.Printf("Error parsing env file: %v\n", err)
-			os.Exit(1)
-		}
-		fmt.Printf("Loaded %d environment variables from %s\n", len(envVars), envFile)
+	// Create deployment request; the image, env and secrets are added below
+	deployReq := client.DeploymentCreate{
+		AppName:       appName,
+		Image:         image,
+		Replicas:      replicas,
+		CPULimit:      cpu,
+		MemoryLimit:   memory,
+		CPURequest:    cpuRequest,
+		MemoryRequest: memoryRequest,
 	}
 
-	// Create client
-	apiClient := client.NewClient(config.BaseURL)
-	apiClient.SetToken(config.AccessToken)
+	// Add persistent storage if specified
+	if persistentVolumeSize != "" && persistentVolumeMountPath != "" {
+		deployReq.PersistentVolumeSize = persistentVolumeSize
+		deployReq.PersistentVolumeMountPath = persistentVolumeMountPath
+	}
 
-	// Handle build from source
-	if isBuild {
-		fmt.Printf("Building from source in %s...\n", buildContext)
+	// Add HTTP port if specified
+	if httpPort > 0 {
+		deployReq.HTTPPort = &httpPort
+	}
 
-		// Validate build context
-		if _, err := os.Stat(buildContext); os.IsNotExist(err) {
-			fmt.Printf("Build context directory does not exist: %s\n", buildContext)
-			os.Exit(1)
-		}
+	// Add TCP port if specified
+	if tcpPort > 0 {
+		deployReq.TCPPort = &tcpPort
+	}
+
+	// Resources are checked together, so that a limit below its request is rejected
+	if err := utils.ValidateDeploymentSpec(&deployReq); err != nil {
+		fmt.Printf("Invalid deployment: %v\n", err)
+		os.Exit(1)
+	}
 
-		// Validate Dockerfile
-		if err := utils.ValidateDockerfile(buildContext, dockerfilePath); err != nil {
-			fmt.Printf("Error: %v\n", err)
+	// Merge environment files and --env overrides
+	var envVars map[string]string
+	if len(envFiles) > 0 || len(envOverrides) > 0 {
+		var sources []utils.EnvVarSource
+		var err error
+		envVars, sources, err = utils.MergeEnv(envFiles, envFormat, envOverrides, "--env")
+		if err != nil {
+			fmt.Printf("Error loading environment variables: %v\n", err)
 			os.Exit(1)
 		}
+		fmt.Printf("Loaded %d environment variables:\n", len(sources))
+		printEnvSources(sources)
+		warnSecretRefs(envVars, "pass it with --secret or --secret-file")
+	}
 
-		// Create build context archive
-		contextArchivePath := utils.GenerateBuildContextPath(appName)
-		defer os.Remove(contextArchivePath) // Clean up
-
-		fmt.Printf("Creating build context archive...\n")
-		if err := utils.CreateBuildContext(buildContext, contextArchivePath); err != nil {
-			fmt.Printf("Error creating build context: %v\n", err)
+	// Merge secret files and --secret overrides
+	var secretVars map[string]string
+	if len(secretFiles) > 0 || len(secretOverrides) > 0 {
+		if containsString(envFiles, utils.StdinEnvFile) && containsString(secretFiles, utils.StdinEnvFile) {
+			fmt.Println("Only one of --env-file and --secret-file can read from stdin (-)")
 			os.Exit(1)
 		}
-
-		// Upload and start build
-		fmt.Printf("Uploading build context and starting build...\n")
-		buildResp, err := apiClient.CreateBuild(contextArchivePath, appName, dockerfilePath)
+		var sources []utils.EnvVarSource
+		var err error
+		secretVars, sources, err = utils.MergeEnv(secretFiles, envFormat, secretOverrides, "--secret")
 		if err != nil {
-			userFriendlyError := parseValidationError(err.Error())
-			fmt.Printf("Build failed: %s\n", userFriendlyError)
+			fmt.Printf("Error loading secrets: %v\n", err)
 			os.Exit(1)
 		}
-
-		fmt.Printf("✅ Build started successfully!\n")
-		fmt.Printf("Build ID: %s\n", buildResp.ID)
-		fmt.Printf("Status: %s\n", buildResp.Status)
-		fmt.Printf("Image URI: %s\n", buildResp.ImageURI)
-
-		// Wait for build to complete
-		fmt.Printf("Waiting for build to complete...\n")
-		for {
-			time.Sleep(5 * time.Second)
-
-			status, err := apiClient.GetBuildStatus(buildResp.ID)
-			if err != nil {
-				fmt.Printf("Error checking build status: %v\n", err)
-				os.Exit(1)
-			}
-
-			fmt.Printf("Build status: %s\n", status.Status)
-
-			if status.Status == "completed" {
-				fmt.Printf("✅ Build completed successfully!\n")
-
-				// Show build logs for successful builds too
-				fmt.Println("\n📋 Build logs:")
-				fmt.Println("================")
-				logs, err := apiClient.GetBuildLogs(status.ID)
-				if err != nil {
-					fmt.Printf("❌ Could not retrieve build logs: %v\n", err)
-				} else if logs == "" {
-					fmt.Println("No logs available")
-				} else {
-					fmt.Println(logs)
-				}
-				fmt.Println("================\n")
-
-				image = status.ImageURI
-				break
-			} else if status.Status == "failed" {
-				fmt.Printf("❌ Build failed!\n")
-
-				// Try to get build logs to show the error
-				fmt.Println("\n📋 Build logs:")
-				fmt.Println("================")
-				logs, err := apiClient.GetBuildLogs(status.ID)
-				if err != nil {
-					fmt.Printf("❌ Could not retrieve build logs: %v\n", err)
-				} else if logs == "" {
-					fmt.Println("No logs available")
-				} else {
-					fmt.Println(logs)
-				}
-				fmt.Println("================")
-
+		for key := range secretVars {
+			if _, ok := envVars[key]; ok {
+				fmt.Printf("%s is set both as a plain variable and as a secret\n", key)
 				os.Exit(1)
 			}
 		}
+		fmt.Printf("Loaded %d secrets:\n", len(sources))
+		printEnvSources(sources)
+		if err := resolveSecretRefs(newAppLogger(""), secretVars); err != nil {
+			fmt.Printf("Error resolving secret references:\n%v\n", err)
+			os.Exit(1)
+		}
 	}
+	warnSecretLookingKeys(envVars)
 
-	// Create deployment request
-	deployReq := client.DeploymentCreate{
-		AppName:         appName,
-		Image:           image,
-		Replicas:        replicas,
-		CPULimit:        cpu,
-		MemoryLimit:     memory,
-		EnvironmentVars: envVars,
+	// With --upsert, an existing app is updated in place
+	var live *client.DeploymentResponse
+	if upsert {
+		var err error
+		live, err = apiClient.FindDeploymentByName(appName)
+		if err != nil && !errors.Is(err, client.ErrDeploymentNotFound) {
+			exitWithError("Error fetching deployment", err)
+		}
 	}
 
-	// Add persistent storage if specified
-	if persistentVolumeSize != "" && persistentVolumeMountPath != "" {
-		deployReq.PersistentVolumeSize = persistentVolumeSize
-		deployReq.PersistentVolumeMountPath = persistentVolumeMountPath
+	// Check the environment before building or deploying anything
+	schemaFile := envSchemaFile
+	if schemaFile == "" {
+		if _, err := os.Stat(utils.DefaultEnvSchemaFile); err == nil {
+			schemaFile = utils.DefaultEnvSchemaFile
+		}
 	}
-
-	// Add HTTP port if specified
-	if httpPort > 0 {
-		deployReq.HTTPPort = &httpPort
+	if schemaFile != "" {
+		schema, err := utils.LoadEnvSchema(schemaFile)
+		if err != nil {
+			fmt.Printf("Invalid env schema:\n%v\n", err)
+			os.Exit(1)
+		}
+		// An existing app keeps its env groups, and its secrets unless new ones are given
+		var groups []string
+		if live != nil {
+			groups = live.EnvGroups
+		}
+		kept, deferred, err := keptEnv(apiClient, live, groups, secretVars == nil)
+		if err != nil {
+			exitWithError("Error checking the environment", err)
+		}
+		if err := checkEnvSchema(schema, deferred, kept, envVars, secretVars); err != nil {
+			fmt.Printf("Environment does not match %s:\n%v\n", schemaFile, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Environment matches %s\n", schemaFile)
 	}
 
-	// Add TCP port if specified
-	if tcpPort > 0 {
-		deployReq.TCPPort = &tcpPort
+	// Handle build from source
+	if isBuild {
+		var err error
+		image, err = buildFromSource(apiClient, newAppLogger(""), appName, buildContext, dockerfilePath)
+		if err != nil {
+			exitWithError("Error", err)
+		}
 	}
 
+	deployReq.Image = image
+	deployReq.EnvironmentVars = envVars
+	deployReq.SecretVars = secretVars
+
 	// Deploy the application
 	if isBuild {
 		fmt.Printf("Deploying built image %s...\n", image)
@@ -408,14 +412,263 @@ func runDeploy(cmd *cobra.Command, args []string) {
 		fmt.Println("ℹ️  Note: Deploy with TCP port will be available in the NodePort range (30000-32767)")
 	}
 
+	if live != nil {
+		deployment := upsertDeployment(apiClient, live, &deployReq)
+		if deployWait {
+			waitForReadyOrExit(apiClient, deployment, deployTimeout)
+		}
+		return
+	}
+
 	deployment, err := apiClient.CreateDeployment(&deployReq)
 	if err != nil {
+		exitIfSessionExpired(err)
 		userFriendlyError := parseValidationError(err.Error())
 		fmt.Printf("Deployment failed: %s\n", userFriendlyError)
 		os.Exit(1)
 	}
 
+	recordRevision(deployment, "created")
+
 	fmt.Println("✅ Deployment created successfully!")
+	printDeployment(deployment)
+
+	if isBuild {
+		fmt.Println("\n🚀 Successfully built and deployed from source!")
+	}
+
+	if deployWait {
+		waitForReadyOrExit(apiClient, deployment, deployTimeout)
+	}
+}
+
+// printEnvSources prints each merged variable with its source, without values
+func printEnvSources(sources []utils.EnvVarSource) {
+	width := 0
+	for _, source := range sources {
+		if len(source.Key) > width {
+			width = len(source.Key)
+		}
+	}
+	for _, source := range sources {
+		line := fmt.Sprintf("  %-*s  %s", width, source.Key, source.Source)
+		if len(source.Overridden) > 0 {
+			line += fmt.Sprintf(" (overrides %s)", strings.Join(source.Overridden, ", "))
+		}
+		fmt.Println(line)
+	}
+}
+
+// warnSecretLookingKeys warns about plain variables whose names suggest secrets
+func warnSecretLookingKeys(envVars map[string]string) {
+	for _, key := range utils.SecretLookingKeys(envVars) {
+		fmt.Fprintf(os.Stderr, "Warning: %s looks like a secret but is a plain variable; pass it with --secret to keep it out of output\n", key)
+	}
+}
+
+// secretResolveTimeout bounds the time spent resolving secret references, including commands
+const secretResolveTimeout = 2 * time.Minute
+
+// secretRegistry resolves vault://, file:// and cmd:// references; it is shared so that
+// each Vault secret is fetched once per run
+var secretRegistry = sync.OnceValue(secrets.DefaultRegistry)
+
+// resolveSecretRefs replaces secret references in envVars with their values and
+// reports which variables were resolved, never printing the values
+func resolveSecretRefs(log *appLogger, envVars map[string]string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretResolveTimeout)
+	defer cancel()
+
+	refs, err := secretRegistry().ResolveEnv(ctx, envVars)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		log.Printf("  %s  resolved from %s\n", ref.Key, ref.Ref)
+	}
+	return nil
+}
+
+// plainSecretRefs returns the plain variables that hold vault:// or cmd:// references. Only
+// secrets are resolved, since the API returns plain values to anyone reading the environment,
+// so these are deployed as the literal text. file:// values are not reported, as plain paths
+// and URLs use them.
+func plainSecretRefs(envVars map[string]string) []string {
+	var keys []string
+	for key, value := range envVars {
+		if scheme, _, ok := secretRegistry().Parse(value); ok && scheme != "file" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// warnSecretRefs warns about plain variables that look like secret references; hint tells the
+// user how to pass them as secrets instead
+func warnSecretRefs(envVars map[string]string, hint string) {
+	for _, key := range plainSecretRefs(envVars) {
+		fmt.Fprintf(os.Stderr, "Warning: %s holds a secret reference but is a plain variable, so it is not resolved; %s\n", key, hint)
+	}
+}
+
+// checkEnvSchema validates layers of variables against an env schema; later layers win
+func checkEnvSchema(schema utils.EnvSchema, deferred func(key, value string) bool, layers ...map[string]string) error {
+	merged := make(map[string]string)
+	for _, layer := range layers {
+		for key, value := range layer {
+			merged[key] = value
+		}
+	}
+	return schema.Validate(merged, deferred)
+}
+
+// keptEnv returns the variables an app gets besides its own when it is deployed: those of the
+// env groups it uses and, when keepSecrets is set, the secrets of the live deployment (nil for
+// a new app). Secret values cannot be read back, so they are placeholders that the returned
+// function reports, for checking an env schema. Groups that cannot be read are an error, since
+// their variables would be reported missing.
+func keptEnv(apiClient *client.Client, live *client.DeploymentResponse, groupNames []string, keepSecrets bool) (map[string]string, func(key, value string) bool, error) {
+	kept := &client.DeploymentResponse{}
+	if live != nil && keepSecrets {
+		kept.SecretKeys = live.SecretKeys
+	}
+
+	groups, err := fetchEnvGroups(apiClient, groupNames)
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make(map[string]string)
+	secrets := make(map[string]bool)
+	for _, v := range utils.EffectiveEnv(kept, groups) {
+		values[v.Key] = v.Value
+		secrets[v.Key] = v.Secret
+	}
+	return values, func(key, value string) bool {
+		return secrets[key] && value == utils.MaskedValue
+	}, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// upsertDeployment replaces the spec of an existing deployment with a rolling update
+// and returns the resulting deployment
+func upsertDeployment(apiClient *client.Client, live *client.DeploymentResponse, deployReq *client.DeploymentCreate) *client.DeploymentResponse {
+	changes := utils.DiffDeployment(live, deployReq)
+	if len(changes) == 0 {
+		fmt.Printf("✅ %s is already up to date\n", live.AppName)
+		return live
+	}
+
+	fmt.Printf("Updating existing deployment %s...\n", live.AppName)
+	printChanges(changes)
+
+	deployment, err := apiClient.UpdateDeployment(live.ID, deployReq)
+	if err != nil {
+		exitIfSessionExpired(err)
+		fmt.Printf("Update failed: %s\n", parseValidationError(err.Error()))
+		os.Exit(1)
+	}
+	recordRevision(deployment, "updated")
+
+	fmt.Println("✅ Deployment updated successfully!")
+	printDeployment(deployment)
+
+	return deployment
+}
+
+// buildFromSource uploads a build context, waits for the build and returns the built image URI
+func buildFromSource(apiClient *client.Client, log *appLogger, appName, contextDir, dockerfile string) (string, error) {
+	log.Printf("Building from source in %s...\n", contextDir)
+
+	// Validate build context
+	if _, err := os.Stat(contextDir); os.IsNotExist(err) {
+		return "", fmt.Errorf("build context directory does not exist: %s", contextDir)
+	}
+
+	// Validate Dockerfile
+	if err := utils.ValidateDockerfile(contextDir, dockerfile); err != nil {
+		return "", err
+	}
+
+	// Create build context archive
+	contextArchivePath := utils.GenerateBuildContextPath(appName)
+	defer os.Remove(contextArchivePath) // Clean up
+
+	log.Printf("Creating build context archive...\n")
+	if err := utils.CreateBuildContext(contextDir, contextArchivePath); err != nil {
+		return "", fmt.Errorf("error creating build context: %w", err)
+	}
+
+	// Upload and start build
+	log.Printf("Uploading build context and starting build...\n")
+	buildResp, err := apiClient.CreateBuild(contextArchivePath, appName, dockerfile)
+	if err != nil {
+		if errors.Is(err, client.ErrSessionExpired) {
+			return "", err
+		}
+		return "", fmt.Errorf("build failed: %s", parseValidationError(err.Error()))
+	}
+
+	log.Printf("✅ Build started successfully!\n")
+	log.Printf("Build ID: %s\n", buildResp.ID)
+	log.Printf("Status: %s\n", buildResp.Status)
+	log.Printf("Image URI: %s\n", buildResp.ImageURI)
+
+	// Wait for build to complete
+	log.Printf("Waiting for build to complete...\n")
+	status, err := apiClient.WaitForBuild(context.Background(), buildResp.ID, client.BuildWaitOptions{
+		OnProgress: func(build *client.BuildResponse) {
+			log.Printf("Build status: %s\n", build.Status)
+		},
+	})
+
+	var failed *client.WaitFailedError
+	if errors.As(err, &failed) {
+		log.Printf("❌ Build failed!\n")
+
+		// Try to get build logs to show the error
+		printBuildLogs(apiClient, log, buildResp.ID)
+
+		return "", fmt.Errorf("build %s failed", buildResp.ID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("✅ Build completed successfully!\n")
+
+	// Show build logs for successful builds too
+	printBuildLogs(apiClient, log, status.ID)
+	log.Println("")
+
+	return status.ImageURI, nil
+}
+
+// printBuildLogs prints the logs of a build between separators
+func printBuildLogs(apiClient *client.Client, log *appLogger, buildID string) {
+	log.Println("\n📋 Build logs:")
+	log.Println("================")
+	logs, err := apiClient.GetBuildLogs(buildID)
+	if err != nil {
+		log.Printf("❌ Could not retrieve build logs: %v\n", err)
+	} else if logs == "" {
+		log.Println("No logs available")
+	} else {
+		log.Println(logs)
+	}
+	log.Println("================")
+}
+
+// printDeployment prints the details of a created or updated deployment
+func printDeployment(deployment *client.DeploymentResponse) {
 	fmt.Printf("Deployment ID: %s\n", deployment.ID)
 	fmt.Printf("App Name: %s\n", deployment.AppName)
 	fmt.Printf("Image: %s\n", deployment.Image)
@@ -445,11 +698,10 @@ func runDeploy(cmd *cobra.Command, args []string) {
 	if len(deployment.EnvironmentVars) > 0 {
 		fmt.Printf("Environment Variables: %d\n", len(deployment.EnvironmentVars))
 	}
+	if len(deployment.SecretKeys) > 0 {
+		fmt.Printf("Secrets: %d\n", len(deployment.SecretKeys))
+	}
 
 	fmt.Printf("Status: %s\n", deployment.Status)
 	fmt.Printf("Created: %s\n", deployment.CreatedAt.Format("2006-01-02 15:04:05"))
-
-	if isBuild {
-		fmt.Println("\n🚀 Successfully built and deployed from source!")
-	}
 }
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/manifest"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff [APP_NAME]",
+	Short: "Show what would change in a live deployment",
+	Long: `Compare a live deployment with a desired spec and show what would change.
+
+The desired spec comes from a manifest (-f) or from deploy-style flags.
+Image, replicas, resources, ports, storage and environment variables are
+compared. Environment values are always masked.
+
+With -f, --overlay merges an environment overlay, as in 'coderun apply'.
+Without -f, --env and --env-file set variables, as in 'coderun deploy'.
+
+Exit codes: 0 when there are no differences, 1 when there are differences,
+2 on errors.
+
+Examples:
+  coderun diff -f coderun.yaml                       # every app in the manifest
+  coderun diff api -f stack.yaml --overlay prod      # one app, with the prod overlay
+  coderun diff web-app --image my-app:v2 --replicas 3 --memory 1Gi
+  coderun diff web-app --env-file base.env --env-file prod.env --env LOG_LEVEL=debug
+  coderun diff -f coderun.yaml --output json         # for CI`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runDiff,
+}
+
+var (
+	diffFile        string
+	diffOverlay     string
+	diffEnv         []string
+	diffVars        []string
+	diffOutput      string
+	diffNoColor     bool
+	diffImage       string
+	diffReplicas    int
+	diffCPU         string
+	diffMemory      string
+	diffHTTPPort    int
+	diffTCPPort     int
+	diffEnvFiles    []string
+	diffEnvFormat   string
+	diffStorageSize string
+	diffStoragePath string
+)
+
+// exitCodeDiffError is the exit code for diff errors, distinct from "differences found"
+const exitCodeDiffError = 2
+
+// appDiff is the diff of one app, as printed in JSON output
+type appDiff struct {
+	App     string              `json:"app"`
+	Exists  bool                `json:"exists"`
+	Changes []utils.FieldChange `json:"changes"`
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&diffFile, "file", "f", "", "Manifest with the desired spec")
+	diffCmd.Flags().StringVar(&diffOverlay, "overlay", "", "Environment overlay to merge into the manifest (e.g. prod loads coderun.prod.yaml)")
+	diffCmd.Flags().StringArrayVar(&diffVars, "var", nil, "Variable for ${VAR} interpolation as KEY=VALUE (repeatable)")
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "text", "Output format: text or json")
+	diffCmd.Flags().BoolVar(&diffNoColor, "no-color", false, "Disable colored output")
+
+	// Deploy-style flags, used when no manifest is given
+	diffCmd.Flags().StringVar(&diffImage, "image", "", "Desired image (not compared when empty)")
+	diffCmd.Flags().IntVar(&diffReplicas, "replicas", 1, "Desired number of replicas")
+	diffCmd.Flags().StringVar(&diffCPU, "cpu", "", "Desired CPU limit")
+	diffCmd.Flags().StringVar(&diffMemory, "memory", "", "Desired memory limit")
+	diffCmd.Flags().IntVar(&diffHTTPPort, "http-port", 0, "Desired HTTP port")
+	diffCmd.Flags().IntVar(&diffTCPPort, "tcp-port", 0, "Desired TCP port")
+	diffCmd.Flags().StringArrayVar(&diffEnv, "env", nil, "Desired environment variable as KEY=VALUE, or KEY to take it from the local environment (repeatable, wins over files)")
+	diffCmd.Flags().StringArrayVar(&diffEnvFiles, "env-file", nil, "Path to a desired environment file (repeatable, later files win, '-' for stdin)")
+	diffCmd.Flags().StringVar(&diffEnvFormat, "env-format", utils.EnvFormatAuto, "Format of --env-file: auto, dotenv, json or yaml")
+	diffCmd.Flags().StringVar(&diffStorageSize, "storage-size", "", "Desired persistent volume size")
+	diffCmd.Flags().StringVar(&diffStoragePath, "storage-path", "", "Desired persistent volume mount path")
+}
+
+// exitDiffError prints an error and exits with the diff error code
+func exitDiffError(message string, err error) {
+	exitIfSessionExpired(err)
+	fmt.Fprintf(os.Stderr, "%s: %v\n", message, err)
+	os.Exit(exitCodeDiffError)
+}
+
+func runDiff(cmd *cobra.Command, args []string) {
+	if diffOutput != "text" && diffOutput != "json" {
+		exitDiffError("Invalid output format", fmt.Errorf("%q (expected text or json)", diffOutput))
+	}
+
+	desired, envGroups := desiredSpecs(cmd, args)
+
+	// Create client
+	apiClient, _ := newAPIClient()
+
+	diffs := make([]appDiff, 0, len(desired))
+	for _, spec := range desired {
+		live, err := apiClient.FindDeploymentByName(spec.AppName)
+		if err != nil && !errors.Is(err, client.ErrDeploymentNotFound) {
+			exitDiffError("Failed to fetch deployment", err)
+		}
+
+		changes := utils.DiffDeployment(live, spec)
+		if groups, ok := envGroups[spec.AppName]; ok {
+			var liveGroups []string
+			if live != nil {
+				liveGroups = live.EnvGroups
+			}
+			changes = append(changes, utils.DiffEnvGroups(liveGroups, groups)...)
+		}
+
+		diffs = append(diffs, appDiff{
+			App:     spec.AppName,
+			Exists:  live != nil,
+			Changes: changes,
+		})
+	}
+
+	changed := false
+	for _, d := range diffs {
+		if len(d.Changes) > 0 {
+			changed = true
+		}
+	}
+
+	if diffOutput == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diffs); err != nil {
+			exitDiffError("Failed to encode diff", err)
+		}
+	} else {
+		printDiffs(diffs, colorEnabled() && !diffNoColor)
+	}
+
+	if changed {
+		os.Exit(1)
+	}
+}
+
+// desiredSpecs builds the desired deployment specs from the manifest or the deploy-style flags,
+// with the env groups of the manifest apps that set env_groups
+func desiredSpecs(cmd *cobra.Command, args []string) ([]*client.DeploymentCreate, map[string][]string) {
+	if diffFile == "" {
+		if len(args) == 0 {
+			exitDiffError("Missing app name", fmt.Errorf("specify APP_NAME or a manifest with -f"))
+		}
+		return []*client.DeploymentCreate{desiredSpecFromFlags(args[0])}, nil
+	}
+
+	if len(diffEnv) > 0 || len(diffEnvFiles) > 0 {
+		exitDiffError("Invalid flags", fmt.Errorf("--env and --env-file cannot be used with -f; set variables in the manifest or an overlay (--overlay)"))
+	}
+	m, err := manifest.Load(diffFile, manifestOptions(diffFile, diffOverlay, diffVars))
+	if err != nil {
+		exitDiffError("Invalid manifest", err)
+	}
+
+	var specs []*client.DeploymentCreate
+	envGroups := make(map[string][]string)
+	for _, app := range m.AllApps() {
+		if len(args) > 0 && app.Name != args[0] {
+			continue
+		}
+		spec, err := app.DeploymentCreate(m.Dir)
+		if err != nil {
+			exitDiffError("Error preparing deployment", err)
+		}
+		for _, key := range plainSecretRefs(spec.EnvironmentVars) {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s holds a secret reference but is a plain variable, so it is not resolved; move it to secrets\n", app.Name, key)
+		}
+		specs = append(specs, spec)
+		if app.EnvGroups != nil {
+			envGroups[app.Name] = app.EnvGroups
+		}
+	}
+	if len(specs) == 0 {
+		exitDiffError("App not found", fmt.Errorf("no app named %s in %s", args[0], diffFile))
+	}
+
+	return specs, envGroups
+}
+
+// desiredSpecFromFlags builds the spec that 'coderun deploy' would send with the same flags
+func desiredSpecFromFlags(name string) *client.DeploymentCreate {
+	spec := &client.DeploymentCreate{
+		AppName:                   name,
+		Image:                     diffImage,
+		Replicas:                  diffReplicas,
+		CPULimit:                  diffCPU,
+		MemoryLimit:               diffMemory,
+		PersistentVolumeSize:      diffStorageSize,
+		PersistentVolumeMountPath: diffStoragePath,
+	}
+	if diffHTTPPort > 0 {
+		spec.HTTPPort = &diffHTTPPort
+	}
+	if diffTCPPort > 0 {
+		spec.TCPPort = &diffTCPPort
+	}
+	if spec.PersistentVolumeSize != "" && spec.Replicas > 1 {
+		spec.Replicas = 1 // deploy forces a single replica with persistent storage
+	}
+
+	if err := utils.ValidateDeploymentSpec(spec); err != nil {
+		exitDiffError("Invalid spec", err)
+	}
+
+	if len(diffEnvFiles) > 0 || len(diffEnv) > 0 {
+		envVars, _, err := utils.MergeEnv(diffEnvFiles, diffEnvFormat, diffEnv, "--env")
+		if err != nil {
+			exitDiffError("Error loading environment variables", err)
+		}
+		warnSecretRefs(envVars, "pass it to deploy with --secret or --secret-file")
+		spec.EnvironmentVars = envVars
+	}
+
+	return spec
+}
+
+// printDiffs prints a unified view of the changes of each app
+func printDiffs(diffs []appDiff, color bool) {
+	for i, d := range diffs {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		if len(d.Changes) == 0 {
+			fmt.Printf("%s: no changes\n", d.App)
+			continue
+		}
+
+		if d.Exists {
+			fmt.Println(colorize(color, colorRed, "--- live/"+d.App))
+		} else {
+			fmt.Println(colorize(color, colorRed, "--- live/"+d.App+" (does not exist)"))
+		}
+		fmt.Println(colorize(color, colorGreen, "+++ desired/"+d.App))
+		fmt.Println(colorize(color, colorCyan, fmt.Sprintf("@@ changes: %d @@", len(d.Changes))))
+
+		for _, change := range d.Changes {
+			if change.Type != utils.ChangeAdded {
+				fmt.Println(colorize(color, colorRed, fmt.Sprintf("- %s: %s", change.Field, change.Live)))
+			}
+			if change.Type != utils.ChangeRemoved {
+				fmt.Println(colorize(color, colorGreen, fmt.Sprintf("+ %s: %s", change.Field, change.Desired)))
+			}
+		}
+	}
+}
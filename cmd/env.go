@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// envCmd represents the env command
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Manage the environment variables of an app",
+	Long: `Read and change the environment variables of a running app without
+redeploying it with a whole env file. Changes roll the app in place.
+
+Secret values are never shown: secrets are listed as ****, and plain
+variables that look like secrets are masked unless --reveal is given.
+
+Examples:
+  coderun env list web-app
+  coderun env get web-app LOG_LEVEL
+  coderun env set web-app LOG_LEVEL=debug FEATURE_X=on
+  coderun env set web-app --secret API_TOKEN
+  coderun env unset web-app FEATURE_X
+  coderun env pull web-app > .env
+  coderun env push web-app .env`,
+}
+
+// envListCmd represents the env list command
+var envListCmd = &cobra.Command{
+	Use:   "list APP_NAME",
+	Short: "List the environment variables of an app",
+	Args:  cobra.ExactArgs(1),
+	Run:   runEnvList,
+}
+
+// envGetCmd represents the env get command
+var envGetCmd = &cobra.Command{
+	Use:   "get APP_NAME KEY",
+	Short: "Print the value of a plain environment variable",
+	Args:  cobra.ExactArgs(2),
+	Run:   runEnvGet,
+}
+
+// envSetCmd represents the env set command
+var envSetCmd = &cobra.Command{
+	Use:   "set APP_NAME KEY=VALUE|KEY...",
+	Short: "Set environment variables (KEY alone takes the value from your shell)",
+	Args:  cobra.MinimumNArgs(2),
+	Run:   runEnvSet,
+}
+
+// envUnsetCmd represents the env unset command
+var envUnsetCmd = &cobra.Command{
+	Use:   "unset APP_NAME KEY...",
+	Short: "Remove environment variables or secrets",
+	Args:  cobra.MinimumNArgs(2),
+	Run:   runEnvUnset,
+}
+
+// envPullCmd represents the env pull command
+var envPullCmd = &cobra.Command{
+	Use:   "pull APP_NAME",
+	Short: "Print the plain environment variables of an app as an env file",
+	Args:  cobra.ExactArgs(1),
+	Run:   runEnvPull,
+}
+
+// envPushCmd represents the env push command
+var envPushCmd = &cobra.Command{
+	Use:   "push APP_NAME FILE",
+	Short: "Replace the plain environment variables of an app with an env file",
+	Long: `Replace the plain environment variables of an app with the contents of an
+env file ('-' reads stdin). Variables missing from the file are removed;
+secrets are left unchanged. The changes are shown before they are applied.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runEnvPush,
+}
+
+var (
+	envReveal      bool
+	envSetSecret   bool
+	envPushYes     bool
+	envPushFormat  string
+	envPullSecrets bool
+)
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+	envCmd.AddCommand(envListCmd)
+	envCmd.AddCommand(envGetCmd)
+	envCmd.AddCommand(envSetCmd)
+	envCmd.AddCommand(envUnsetCmd)
+	envCmd.AddCommand(envPullCmd)
+	envCmd.AddCommand(envPushCmd)
+
+	envListCmd.Flags().BoolVar(&envReveal, "reveal", false, "Show values of plain variables that look like secrets")
+	envSetCmd.Flags().BoolVar(&envSetSecret, "secret", false, "Store the variables as secrets")
+	envPullCmd.Flags().BoolVar(&envPullSecrets, "include-secret-keys", false, "Add secret keys as ${KEY} placeholders")
+	envPushCmd.Flags().BoolVarP(&envPushYes, "yes", "y", false, "Apply without asking for confirmation")
+	envPushCmd.Flags().StringVar(&envPushFormat, "env-format", utils.EnvFormatAuto, "Format of FILE: auto, dotenv, json or yaml")
+}
+
+func runEnvList(cmd *cobra.Command, args []string) {
+	apiClient, _ := newAPIClient()
+	deployment := findDeploymentOrExit(apiClient, args[0])
+
+	if len(deployment.EnvironmentVars) == 0 && len(deployment.SecretKeys) == 0 {
+		fmt.Printf("%s has no environment variables\n", deployment.AppName)
+		return
+	}
+
+	var rows [][]string
+	for key, value := range deployment.EnvironmentVars {
+		if !envReveal && utils.LooksLikeSecret(key) {
+			value = utils.MaskedValue
+		}
+		rows = append(rows, []string{key, value, "plain"})
+	}
+	for _, key := range deployment.SecretKeys {
+		rows = append(rows, []string{key, utils.MaskedValue, "secret"})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+
+	printTable([]string{"KEY", "VALUE", "TYPE"}, rows)
+}
+
+func runEnvGet(cmd *cobra.Command, args []string) {
+	apiClient, _ := newAPIClient()
+	deployment := findDeploymentOrExit(apiClient, args[0])
+	key := args[1]
+
+	if value, ok := deployment.EnvironmentVars[key]; ok {
+		fmt.Println(value)
+		return
+	}
+	if containsString(deployment.SecretKeys, key) {
+		fmt.Fprintf(os.Stderr, "%s is a secret; its value cannot be read\n", key)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "%s is not set on %s\n", key, deployment.AppName)
+	os.Exit(1)
+}
+
+func runEnvSet(cmd *cobra.Command, args []string) {
+	flag := "env set"
+	if envSetSecret {
+		flag = "env set --secret"
+	}
+	values, _, err := utils.MergeEnv(nil, utils.EnvFormatAuto, args[1:], flag)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if envSetSecret {
+		if err := resolveSecretRefs(newAppLogger(""), values); err != nil {
+			fmt.Printf("Error resolving secret references:\n%v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		warnSecretRefs(values, "use --secret")
+	}
+
+	apiClient, _ := newAPIClient()
+	deployment := findDeploymentOrExit(apiClient, args[0])
+
+	update := &client.EnvUpdate{}
+	if envSetSecret {
+		for key := range values {
+			if _, ok := deployment.EnvironmentVars[key]; ok {
+				fmt.Printf("%s is a plain variable; unset it before storing it as a secret\n", key)
+				os.Exit(1)
+			}
+		}
+		update.SetSecrets = values
+	} else {
+		for key := range values {
+			if containsString(deployment.SecretKeys, key) {
+				fmt.Printf("%s is a secret; use --secret to change it\n", key)
+				os.Exit(1)
+			}
+		}
+		update.Set = values
+		warnSecretLookingKeys(values)
+	}
+
+	changeEnv(apiClient, deployment, update)
+}
+
+func runEnvUnset(cmd *cobra.Command, args []string) {
+	apiClient, _ := newAPIClient()
+	deployment := findDeploymentOrExit(apiClient, args[0])
+
+	keys := args[1:]
+	for _, key := range keys {
+		if _, ok := deployment.EnvironmentVars[key]; !ok && !containsString(deployment.SecretKeys, key) {
+			fmt.Printf("%s is not set on %s\n", key, deployment.AppName)
+			os.Exit(1)
+		}
+	}
+
+	update := &client.EnvUpdate{Unset: keys}
+	changeEnv(apiClient, deployment, update)
+}
+
+func runEnvPull(cmd *cobra.Command, args []string) {
+	apiClient, _ := newAPIClient()
+	deployment := findDeploymentOrExit(apiClient, args[0])
+
+	keys := make([]string, 0, len(deployment.EnvironmentVars))
+	for key := range deployment.EnvironmentVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Printf("# Environment of %s\n", deployment.AppName)
+	for _, key := range keys {
+		fmt.Printf("%s=%s\n", key, utils.DotenvQuote(deployment.EnvironmentVars[key]))
+	}
+
+	if len(deployment.SecretKeys) > 0 {
+		secretKeys := append([]string(nil), deployment.SecretKeys...)
+		sort.Strings(secretKeys)
+		if envPullSecrets {
+			fmt.Println("# Secrets (values are not readable; filled from your shell)")
+			for _, key := range secretKeys {
+				fmt.Printf("%s=%s\n", key, "${"+key+"}")
+			}
+		} else {
+			fmt.Printf("# Secrets not included: %s\n", strings.Join(secretKeys, ", "))
+		}
+	}
+}
+
+func runEnvPush(cmd *cobra.Command, args []string) {
+	file := args[1]
+	if file == utils.StdinEnvFile && !envPushYes {
+		fmt.Println("Reading the env file from stdin requires --yes, since confirmation is read from stdin")
+		os.Exit(1)
+	}
+
+	values, err := utils.ReadEnvFile(file, envPushFormat)
+	if err != nil {
+		fmt.Printf("Error parsing env file: %v\n", err)
+		os.Exit(1)
+	}
+	warnSecretRefs(values, "store it with 'coderun env set APP --secret'")
+
+	apiClient, _ := newAPIClient()
+	deployment := findDeploymentOrExit(apiClient, args[0])
+
+	for key := range values {
+		if containsString(deployment.SecretKeys, key) {
+			fmt.Printf("%s is a secret on %s; remove it from the file or unset the secret first\n", key, deployment.AppName)
+			os.Exit(1)
+		}
+	}
+	warnSecretLookingKeys(values)
+
+	update := &client.EnvUpdate{Set: values, Replace: true}
+	changes := envChanges(deployment, update)
+	if len(changes) == 0 {
+		fmt.Printf("✅ %s already has this environment\n", deployment.AppName)
+		return
+	}
+
+	fmt.Printf("Changes to %s:\n", deployment.AppName)
+	printChanges(changes)
+
+	if !envPushYes && !confirm(fmt.Sprintf("Apply %d change(s) to %s?", len(changes), deployment.AppName)) {
+		fmt.Println("Aborted")
+		os.Exit(1)
+	}
+
+	applyEnvUpdate(apiClient, deployment, update)
+}
+
+// envChanges returns the changes an env update makes to a deployment, with masked values
+func envChanges(deployment *client.DeploymentResponse, update *client.EnvUpdate) []utils.FieldChange {
+	desired := make(map[string]string)
+	if !update.Replace {
+		for key, value := range deployment.EnvironmentVars {
+			desired[key] = value
+		}
+	}
+	for key, value := range update.Set {
+		desired[key] = value
+	}
+
+	secrets := make(map[string]string)
+	for _, key := range deployment.SecretKeys {
+		secrets[key] = ""
+	}
+	for key := range update.SetSecrets {
+		secrets[key] = ""
+	}
+
+	for _, key := range update.Unset {
+		delete(desired, key)
+		delete(secrets, key)
+	}
+
+	changes := utils.DiffEnv(deployment.EnvironmentVars, desired)
+	changes = append(changes, utils.DiffSecrets(deployment.SecretKeys, secrets)...)
+	for key := range update.SetSecrets {
+		if containsString(deployment.SecretKeys, key) {
+			changes = append(changes, utils.FieldChange{Field: "secret." + key, Type: utils.ChangeModified, Live: utils.MaskedValue, Desired: utils.MaskedValue})
+		}
+	}
+	return changes
+}
+
+// changeEnv prints the changes an env update makes and applies it, unless there are none
+func changeEnv(apiClient *client.Client, deployment *client.DeploymentResponse, update *client.EnvUpdate) {
+	changes := envChanges(deployment, update)
+	if len(changes) == 0 {
+		fmt.Printf("✅ %s already has these values\n", deployment.AppName)
+		return
+	}
+
+	fmt.Printf("Updating environment of %s...\n", deployment.AppName)
+	printChanges(changes)
+	applyEnvUpdate(apiClient, deployment, update)
+}
+
+// applyEnvUpdate sends an env update and records the resulting revision
+func applyEnvUpdate(apiClient *client.Client, deployment *client.DeploymentResponse, update *client.EnvUpdate) {
+	updated, err := apiClient.UpdateDeploymentEnv(deployment.ID, update)
+	if err != nil {
+		exitIfSessionExpired(err)
+		fmt.Printf("Update failed: %s\n", parseValidationError(err.Error()))
+		os.Exit(1)
+	}
+	recordRevision(updated, "env")
+
+	fmt.Printf("✅ Environment of %s updated, rolling out\n", updated.AppName)
+}
@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// envEncryptCmd represents the env encrypt command
+var envEncryptCmd = &cobra.Command{
+	Use:   "encrypt FILE",
+	Short: "Encrypt the values of an env file so it can be committed",
+	Long: `Encrypt the values of an env file in place with age X25519 recipients.
+Keys stay readable, so diffs show which variables changed. --env-file
+decrypts such files transparently. Values are stored as written: $VAR
+references are not expanded.
+
+Recipients default to the public key of your own identity, read from
+CODERUN_AGE_KEY, CODERUN_AGE_KEY_FILE or ~/.coderun/age.key (create one
+with 'age-keygen -o ~/.coderun/age.key'). Running encrypt on an encrypted
+file with --recipient changes its recipients.
+
+Examples:
+  coderun env encrypt prod.env --recipient age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+  coderun env encrypt config.yaml -o prod.env
+  coderun env decrypt prod.env
+  coderun env edit prod.env`,
+	Args: cobra.ExactArgs(1),
+	Run:  runEnvEncrypt,
+}
+
+// envDecryptCmd represents the env decrypt command
+var envDecryptCmd = &cobra.Command{
+	Use:   "decrypt FILE",
+	Short: "Print the decrypted contents of an encrypted env file",
+	Args:  cobra.ExactArgs(1),
+	Run:   runEnvDecrypt,
+}
+
+// envEditCmd represents the env edit command
+var envEditCmd = &cobra.Command{
+	Use:   "edit FILE",
+	Short: "Edit an encrypted env file in $EDITOR, re-encrypting only changed values",
+	Long: `Decrypt an env file to a private temporary file, open it in $VISUAL or
+$EDITOR, then encrypt it back. Unchanged values keep their ciphertext, and
+values are stored as written, without expanding $VAR references.
+A file that does not exist is created, encrypted for --recipient or your
+own identity.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runEnvEdit,
+}
+
+var (
+	envCryptRecipients []string
+	envCryptOutput     string
+	envCryptFormat     string
+)
+
+func init() {
+	envCmd.AddCommand(envEncryptCmd)
+	envCmd.AddCommand(envDecryptCmd)
+	envCmd.AddCommand(envEditCmd)
+
+	envEncryptCmd.Flags().StringArrayVarP(&envCryptRecipients, "recipient", "r", nil, "age recipient public key (repeatable)")
+	envEncryptCmd.Flags().StringVarP(&envCryptOutput, "output", "o", "", "Write to this file instead of FILE ('-' for stdout)")
+	envEncryptCmd.Flags().StringVar(&envCryptFormat, "env-format", utils.EnvFormatAuto, "Format of FILE: auto, dotenv, json or yaml")
+	envDecryptCmd.Flags().StringVarP(&envCryptOutput, "output", "o", "", "Write to this file instead of stdout")
+	envEditCmd.Flags().StringArrayVarP(&envCryptRecipients, "recipient", "r", nil, "age recipient public key for a new file (repeatable)")
+}
+
+func runEnvEncrypt(cmd *cobra.Command, args []string) {
+	file := args[0]
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", file, err)
+		os.Exit(1)
+	}
+
+	var env *utils.EncryptedEnv
+	if utils.IsEncryptedEnv(data) {
+		if len(envCryptRecipients) == 0 {
+			fmt.Printf("%s is already encrypted; use 'coderun env edit' to change it, or --recipient to change its recipients\n", file)
+			os.Exit(1)
+		}
+		env, err = utils.DecryptEnv(data, file)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		env.Recipients = envCryptRecipients
+	} else {
+		values, err := utils.ReadEnvFileLiteral(file, envCryptFormat)
+		if err != nil {
+			fmt.Printf("Error parsing env file: %v\n", err)
+			os.Exit(1)
+		}
+		env = utils.NewEncryptedEnv(values, envRecipientsOrExit())
+	}
+
+	encrypted, err := env.Marshal()
+	if err != nil {
+		fmt.Printf("Error encrypting %s: %v\n", file, err)
+		os.Exit(1)
+	}
+
+	output := file
+	if envCryptOutput != "" {
+		output = envCryptOutput
+	}
+	if output == "-" {
+		fmt.Print(string(encrypted))
+		return
+	}
+	if err := os.WriteFile(output, encrypted, 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Encrypted %d variables in %s for %d recipient(s)\n", len(env.Values), output, len(env.Recipients))
+}
+
+func runEnvDecrypt(cmd *cobra.Command, args []string) {
+	env := readEncryptedEnvOrExit(args[0])
+
+	plaintext := formatDotenv(env.Values)
+	if envCryptOutput == "" || envCryptOutput == "-" {
+		fmt.Print(plaintext)
+		return
+	}
+	if err := os.WriteFile(envCryptOutput, []byte(plaintext), 0600); err != nil {
+		fmt.Printf("Error writing %s: %v\n", envCryptOutput, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Decrypted %d variables to %s; do not commit it\n", len(env.Values), envCryptOutput)
+}
+
+func runEnvEdit(cmd *cobra.Command, args []string) {
+	file := args[0]
+
+	var env *utils.EncryptedEnv
+	if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+		env = utils.NewEncryptedEnv(map[string]string{}, envRecipientsOrExit())
+	} else {
+		env = readEncryptedEnvOrExit(file)
+	}
+
+	tempDir, err := os.MkdirTemp("", "coderun-env-")
+	if err != nil {
+		fmt.Printf("Error creating temporary file: %v\n", err)
+		os.Exit(1)
+	}
+	// The temporary file holds plaintext; remove it however the edit ends
+	defer os.RemoveAll(tempDir)
+	tempFile := filepath.Join(tempDir, filepath.Base(file))
+	if err := os.WriteFile(tempFile, []byte(formatDotenv(env.Values)), 0600); err != nil {
+		fmt.Printf("Error writing temporary file: %v\n", err)
+		os.RemoveAll(tempDir)
+		os.Exit(1)
+	}
+
+	var values map[string]string
+	for {
+		if err := runEditor(tempFile); err != nil {
+			fmt.Printf("Editor failed: %v\n", err)
+			os.RemoveAll(tempDir)
+			os.Exit(1)
+		}
+
+		edited, err := os.ReadFile(tempFile)
+		if err == nil {
+			var warnings []string
+			values, warnings, err = utils.ParseDotenvLiteral(edited, file)
+			for _, warning := range warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			}
+		}
+		if err == nil {
+			break
+		}
+
+		fmt.Printf("Error: %v\n", err)
+		if !confirm("Edit again?") {
+			fmt.Println("Aborted; no changes written")
+			os.RemoveAll(tempDir)
+			os.Exit(1)
+		}
+	}
+
+	changes := utils.DiffEnv(env.Values, values)
+	if len(changes) == 0 {
+		fmt.Println("No changes")
+		return
+	}
+
+	env.Values = values
+	encrypted, err := env.Marshal()
+	if err != nil {
+		fmt.Printf("Error encrypting %s: %v\n", file, err)
+		os.RemoveAll(tempDir)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(file, encrypted, 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", file, err)
+		os.RemoveAll(tempDir)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Updated %s:\n", file)
+	printChanges(changes)
+}
+
+// readEncryptedEnvOrExit decrypts an encrypted env file, exiting on errors
+func readEncryptedEnvOrExit(file string) *utils.EncryptedEnv {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", file, err)
+		os.Exit(1)
+	}
+	if !utils.IsEncryptedEnv(data) {
+		fmt.Printf("%s is not encrypted; use 'coderun env encrypt' first\n", file)
+		os.Exit(1)
+	}
+
+	env, err := utils.DecryptEnv(data, file)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	return env
+}
+
+// envRecipientsOrExit returns the --recipient flags, or the public keys of your own identities
+func envRecipientsOrExit() []string {
+	if len(envCryptRecipients) > 0 {
+		return envCryptRecipients
+	}
+
+	identities, err := utils.LoadAgeIdentities()
+	if err != nil {
+		fmt.Printf("No recipients: pass --recipient, or %v\n", err)
+		os.Exit(1)
+	}
+	recipients := utils.AgeRecipientsOf(identities)
+	if len(recipients) == 0 {
+		fmt.Println("No recipients: pass --recipient, or use an X25519 age identity")
+		os.Exit(1)
+	}
+	return recipients
+}
+
+// formatDotenv renders values as a sorted env file that ParseDotenv reads back unchanged
+func formatDotenv(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", key, utils.DotenvQuote(values[key]))
+	}
+	return b.String()
+}
+
+// runEditor opens a file in $VISUAL or $EDITOR through the shell, so editors with arguments work
+func runEditor(file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	var command *exec.Cmd
+	if runtime.GOOS == "windows" {
+		if editor == "" {
+			editor = "notepad"
+		}
+		command = exec.Command("cmd", "/C", editor+` "`+file+`"`)
+	} else {
+		if editor == "" {
+			editor = "vi"
+		}
+		command = exec.Command("sh", "-c", editor+" "+shellQuote(file))
+	}
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	return command.Run()
+}
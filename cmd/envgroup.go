@@ -0,0 +1,397 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// envgroupCmd represents the envgroup command
+var envgroupCmd = &cobra.Command{
+	Use:   "envgroup",
+	Short: "Manage environment variables shared by several apps",
+	Long: `Manage env groups: named sets of environment variables stored on the
+server and shared by every app they are attached to. Changing a group
+rolls the apps that use it.
+
+An app's own variables override its groups, and groups attached later
+override earlier ones. 'coderun status' shows where each variable of an
+app comes from.
+
+Examples:
+  coderun envgroup create shared-db DATABASE_URL --secret
+  coderun envgroup create observability --env-file observability.env
+  coderun envgroup set observability SENTRY_ENVIRONMENT=production
+  coderun envgroup unset observability OLD_FLAG
+  coderun envgroup attach shared-db web-app
+  coderun envgroup detach shared-db web-app
+  coderun envgroup list
+  coderun envgroup list observability`,
+}
+
+// envgroupCreateCmd represents the envgroup create command
+var envgroupCreateCmd = &cobra.Command{
+	Use:   "create NAME [KEY=VALUE|KEY...]",
+	Short: "Create an env group",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runEnvgroupCreate,
+}
+
+// envgroupSetCmd represents the envgroup set command
+var envgroupSetCmd = &cobra.Command{
+	Use:   "set NAME KEY=VALUE|KEY...",
+	Short: "Set variables of an env group (KEY alone takes the value from your shell)",
+	Args:  cobra.MinimumNArgs(2),
+	Run:   runEnvgroupSet,
+}
+
+// envgroupUnsetCmd represents the envgroup unset command
+var envgroupUnsetCmd = &cobra.Command{
+	Use:   "unset NAME KEY...",
+	Short: "Remove variables from an env group",
+	Args:  cobra.MinimumNArgs(2),
+	Run:   runEnvgroupUnset,
+}
+
+// envgroupListCmd represents the envgroup list command
+var envgroupListCmd = &cobra.Command{
+	Use:   "list [NAME]",
+	Short: "List env groups, or the variables of one group",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runEnvgroupList,
+}
+
+// envgroupDeleteCmd represents the envgroup delete command
+var envgroupDeleteCmd = &cobra.Command{
+	Use:   "delete NAME",
+	Short: "Delete an env group that no app uses",
+	Args:  cobra.ExactArgs(1),
+	Run:   runEnvgroupDelete,
+}
+
+// envgroupAttachCmd represents the envgroup attach command
+var envgroupAttachCmd = &cobra.Command{
+	Use:   "attach NAME APP_NAME",
+	Short: "Make an app use an env group",
+	Args:  cobra.ExactArgs(2),
+	Run:   runEnvgroupAttach,
+}
+
+// envgroupDetachCmd represents the envgroup detach command
+var envgroupDetachCmd = &cobra.Command{
+	Use:   "detach NAME APP_NAME",
+	Short: "Stop an app from using an env group",
+	Args:  cobra.ExactArgs(2),
+	Run:   runEnvgroupDetach,
+}
+
+var (
+	envgroupSecret    bool
+	envgroupEnvFiles  []string
+	envgroupEnvFormat string
+	envgroupReveal    bool
+)
+
+func init() {
+	rootCmd.AddCommand(envgroupCmd)
+	envgroupCmd.AddCommand(envgroupCreateCmd)
+	envgroupCmd.AddCommand(envgroupSetCmd)
+	envgroupCmd.AddCommand(envgroupUnsetCmd)
+	envgroupCmd.AddCommand(envgroupListCmd)
+	envgroupCmd.AddCommand(envgroupDeleteCmd)
+	envgroupCmd.AddCommand(envgroupAttachCmd)
+	envgroupCmd.AddCommand(envgroupDetachCmd)
+
+	envgroupCreateCmd.Flags().BoolVar(&envgroupSecret, "secret", false, "Store the variables as secrets")
+	envgroupCreateCmd.Flags().StringArrayVar(&envgroupEnvFiles, "env-file", nil, "Environment file with the group's variables (repeatable, later files win)")
+	envgroupCreateCmd.Flags().StringVar(&envgroupEnvFormat, "env-format", utils.EnvFormatAuto, "Format of --env-file: auto, dotenv, json or yaml")
+	envgroupSetCmd.Flags().BoolVar(&envgroupSecret, "secret", false, "Store the variables as secrets")
+	envgroupListCmd.Flags().BoolVar(&envgroupReveal, "reveal", false, "Show values of plain variables that look like secrets")
+}
+
+// readEnvgroupVars merges env files and KEY=VALUE arguments, resolving secret references of secrets
+func readEnvgroupVars(files, args []string) map[string]string {
+	flag := "envgroup"
+	if envgroupSecret {
+		flag = "envgroup --secret"
+	}
+	values, _, err := utils.MergeEnv(files, envgroupEnvFormat, args, flag)
+	if err != nil {
+		fmt.Printf("Error loading environment variables: %v\n", err)
+		os.Exit(1)
+	}
+	if !envgroupSecret {
+		warnSecretRefs(values, "use --secret")
+		return values
+	}
+	if err := resolveSecretRefs(newAppLogger(""), values); err != nil {
+		fmt.Printf("Error resolving secret references:\n%v\n", err)
+		os.Exit(1)
+	}
+	return values
+}
+
+func runEnvgroupCreate(cmd *cobra.Command, args []string) {
+	name := args[0]
+	if err := utils.ValidateAppName(name); err != nil {
+		// Group names follow the app name rules
+		fmt.Printf("Invalid env group name: %s\n", strings.Replace(err.Error(), "app name", "name", 1))
+		os.Exit(1)
+	}
+	values := readEnvgroupVars(envgroupEnvFiles, args[1:])
+
+	create := &client.EnvGroupCreate{Name: name}
+	if envgroupSecret {
+		create.SecretVars = values
+	} else {
+		create.Vars = values
+		warnSecretLookingKeys(values)
+	}
+
+	apiClient, _ := newAPIClient()
+	group, err := apiClient.CreateEnvGroup(create)
+	if err != nil {
+		exitIfSessionExpired(err)
+		fmt.Printf("Failed to create env group: %s\n", parseValidationError(err.Error()))
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Env group %s created with %d variable(s)\n", group.Name, len(group.Vars)+len(group.SecretKeys))
+	fmt.Printf("Attach it with: coderun envgroup attach %s APP_NAME\n", group.Name)
+}
+
+func runEnvgroupSet(cmd *cobra.Command, args []string) {
+	values := readEnvgroupVars(nil, args[1:])
+
+	apiClient, _ := newAPIClient()
+	group := getEnvGroupOrExit(apiClient, args[0])
+
+	update := &client.EnvUpdate{}
+	if envgroupSecret {
+		for key := range values {
+			if _, ok := group.Vars[key]; ok {
+				fmt.Printf("%s is a plain variable of %s; unset it before storing it as a secret\n", key, group.Name)
+				os.Exit(1)
+			}
+		}
+		update.SetSecrets = values
+	} else {
+		for key := range values {
+			if containsString(group.SecretKeys, key) {
+				fmt.Printf("%s is a secret of %s; use --secret to change it\n", key, group.Name)
+				os.Exit(1)
+			}
+		}
+		update.Set = values
+		warnSecretLookingKeys(values)
+	}
+
+	updateEnvGroup(apiClient, group, update)
+}
+
+func runEnvgroupUnset(cmd *cobra.Command, args []string) {
+	apiClient, _ := newAPIClient()
+	group := getEnvGroupOrExit(apiClient, args[0])
+
+	keys := args[1:]
+	for _, key := range keys {
+		if _, ok := group.Vars[key]; !ok && !containsString(group.SecretKeys, key) {
+			fmt.Printf("%s is not set in %s\n", key, group.Name)
+			os.Exit(1)
+		}
+	}
+
+	updateEnvGroup(apiClient, group, &client.EnvUpdate{Unset: keys})
+}
+
+// updateEnvGroup prints the changes of an env group update and applies it, unless there are none
+func updateEnvGroup(apiClient *client.Client, group *client.EnvGroup, update *client.EnvUpdate) {
+	// Env groups have the same shape as a deployment's own environment
+	changes := envChanges(&client.DeploymentResponse{EnvironmentVars: group.Vars, SecretKeys: group.SecretKeys}, update)
+	if len(changes) == 0 {
+		fmt.Printf("✅ %s already has these values\n", group.Name)
+		return
+	}
+
+	fmt.Printf("Updating env group %s...\n", group.Name)
+	printChanges(changes)
+
+	updated, err := apiClient.UpdateEnvGroup(group.Name, update)
+	if err != nil {
+		exitIfSessionExpired(err)
+		fmt.Printf("Update failed: %s\n", parseValidationError(err.Error()))
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Env group %s updated\n", updated.Name)
+	if len(updated.Deployments) > 0 {
+		fmt.Printf("Rolling %d app(s) that use it: %s\n", len(updated.Deployments), strings.Join(updated.Deployments, ", "))
+	}
+}
+
+func runEnvgroupList(cmd *cobra.Command, args []string) {
+	apiClient, _ := newAPIClient()
+
+	if len(args) == 1 {
+		printEnvGroup(getEnvGroupOrExit(apiClient, args[0]))
+		return
+	}
+
+	groupList, err := apiClient.ListEnvGroups()
+	if err != nil {
+		exitWithError("Failed to fetch env groups", err)
+	}
+	if len(groupList.EnvGroups) == 0 {
+		fmt.Println("No env groups found.")
+		return
+	}
+
+	groups := groupList.EnvGroups
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+
+	var rows [][]string
+	for _, group := range groups {
+		rows = append(rows, []string{
+			group.Name,
+			fmt.Sprintf("%d", len(group.Vars)),
+			fmt.Sprintf("%d", len(group.SecretKeys)),
+			valueOrDash(strings.Join(group.Deployments, ", ")),
+		})
+	}
+	printTable([]string{"NAME", "VARS", "SECRETS", "APPS"}, rows)
+}
+
+// printEnvGroup lists the variables of a group with secret values masked
+func printEnvGroup(group *client.EnvGroup) {
+	fmt.Printf("Env group %s, used by: %s\n\n", group.Name, valueOrDash(strings.Join(group.Deployments, ", ")))
+	if len(group.Vars) == 0 && len(group.SecretKeys) == 0 {
+		fmt.Println("No variables")
+		return
+	}
+
+	var rows [][]string
+	for key, value := range group.Vars {
+		if !envgroupReveal && utils.LooksLikeSecret(key) {
+			value = utils.MaskedValue
+		}
+		rows = append(rows, []string{key, value, "plain"})
+	}
+	for _, key := range group.SecretKeys {
+		rows = append(rows, []string{key, utils.MaskedValue, "secret"})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+
+	printTable([]string{"KEY", "VALUE", "TYPE"}, rows)
+}
+
+func runEnvgroupDelete(cmd *cobra.Command, args []string) {
+	apiClient, _ := newAPIClient()
+	group := getEnvGroupOrExit(apiClient, args[0])
+
+	if len(group.Deployments) > 0 {
+		fmt.Printf("Env group %s is used by %s; detach it first\n", group.Name, strings.Join(group.Deployments, ", "))
+		os.Exit(1)
+	}
+
+	if err := apiClient.DeleteEnvGroup(group.Name); err != nil {
+		exitWithError("Failed to delete env group", err)
+	}
+	fmt.Printf("✅ Env group %s deleted\n", group.Name)
+}
+
+func runEnvgroupAttach(cmd *cobra.Command, args []string) {
+	apiClient, _ := newAPIClient()
+	group := getEnvGroupOrExit(apiClient, args[0])
+	deployment := findDeploymentOrExit(apiClient, args[1])
+
+	if containsString(deployment.EnvGroups, group.Name) {
+		fmt.Printf("✅ %s already uses %s\n", deployment.AppName, group.Name)
+		return
+	}
+
+	// Report keys the app or earlier groups already set, since precedence decides which value wins
+	groups := append(fetchEnvGroupsOrWarn(apiClient, deployment.EnvGroups), group)
+	for _, v := range utils.EffectiveEnv(deployment, groups) {
+		if v.Source == "app" && (containsString(group.SecretKeys, v.Key) || hasKey(group.Vars, v.Key)) {
+			fmt.Printf("Note: %s is set by %s itself, which overrides %s\n", v.Key, deployment.AppName, group.Name)
+		} else if v.Source == "group "+group.Name && len(v.Overridden) > 0 {
+			fmt.Printf("Note: %s from %s overrides %s\n", v.Key, group.Name, strings.Join(v.Overridden, ", "))
+		}
+	}
+
+	updated, err := apiClient.AttachEnvGroup(deployment.ID, group.Name)
+	if err != nil {
+		exitIfSessionExpired(err)
+		fmt.Printf("Attach failed: %s\n", parseValidationError(err.Error()))
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ %s now uses %s (groups: %s), rolling out\n", updated.AppName, group.Name, strings.Join(updated.EnvGroups, ", "))
+}
+
+func runEnvgroupDetach(cmd *cobra.Command, args []string) {
+	apiClient, _ := newAPIClient()
+	deployment := findDeploymentOrExit(apiClient, args[1])
+
+	if !containsString(deployment.EnvGroups, args[0]) {
+		fmt.Printf("%s does not use env group %s\n", deployment.AppName, args[0])
+		os.Exit(1)
+	}
+
+	updated, err := apiClient.DetachEnvGroup(deployment.ID, args[0])
+	if err != nil {
+		exitIfSessionExpired(err)
+		fmt.Printf("Detach failed: %s\n", parseValidationError(err.Error()))
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ %s no longer uses %s, rolling out\n", updated.AppName, args[0])
+}
+
+// getEnvGroupOrExit fetches an env group by name, exiting when it cannot be found
+func getEnvGroupOrExit(apiClient *client.Client, name string) *client.EnvGroup {
+	group, err := apiClient.GetEnvGroup(name)
+	if err != nil {
+		exitWithError(fmt.Sprintf("Failed to fetch env group %s", name), err)
+	}
+	return group
+}
+
+// fetchEnvGroups fetches env groups in order, failing on the first that cannot be read
+func fetchEnvGroups(apiClient *client.Client, names []string) ([]*client.EnvGroup, error) {
+	groups := make([]*client.EnvGroup, 0, len(names))
+	for _, name := range names {
+		group, err := apiClient.GetEnvGroup(name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read env group %s: %w", name, err)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// fetchEnvGroupsOrWarn fetches env groups in order, skipping (with a warning) those that cannot be read
+func fetchEnvGroupsOrWarn(apiClient *client.Client, names []string) []*client.EnvGroup {
+	groups := make([]*client.EnvGroup, 0, len(names))
+	for _, name := range names {
+		group, err := apiClient.GetEnvGroup(name)
+		if err != nil {
+			exitIfSessionExpired(err)
+			fmt.Fprintf(os.Stderr, "Warning: cannot read env group %s: %v\n", name, err)
+			continue
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func hasKey(values map[string]string, key string) bool {
+	_, ok := values[key]
+	return ok
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/manifest"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export [APP_NAME]",
+	Short: "Export live deployments as a manifest or deploy command",
+	Long: `Export live deployments as a coderun.yaml manifest, or as the equivalent
+'coderun deploy' command line, so existing apps can be kept in version control.
+
+Values of environment variables that look like secrets (*_KEY, *_SECRET,
+*_TOKEN, *PASSWORD*, ...) are replaced by ${NAME} placeholders unless
+--include-secrets is given. Secrets are always exported as placeholders,
+since their values are never returned. 'coderun apply' fills placeholders
+from --var flags or the environment.
+
+Examples:
+  coderun export web-app > coderun.yaml
+  coderun export --all > stack.yaml
+  coderun export web-app --format command`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runExport,
+}
+
+var (
+	exportAll            bool
+	exportFormat         string
+	exportIncludeSecrets bool
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().BoolVar(&exportAll, "all", false, "Export every deployment")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "manifest", "Output format: manifest or command")
+	exportCmd.Flags().BoolVar(&exportIncludeSecrets, "include-secrets", false, "Include secret-looking values instead of placeholders")
+}
+
+func runExport(cmd *cobra.Command, args []string) {
+	if exportAll == (len(args) == 1) {
+		fmt.Println("Specify either APP_NAME or --all")
+		os.Exit(1)
+	}
+	if exportFormat != "manifest" && exportFormat != "command" {
+		fmt.Printf("Invalid format '%s' (expected manifest or command)\n", exportFormat)
+		os.Exit(1)
+	}
+
+	// Create client
+	apiClient, _ := newAPIClient()
+
+	var deployments []client.DeploymentResponse
+	if exportAll {
+		deploymentList, err := apiClient.ListDeployments()
+		if err != nil {
+			exitWithError("Failed to fetch deployments", err)
+		}
+		deployments = deploymentList.Deployments
+		sort.Slice(deployments, func(i, j int) bool {
+			return deployments[i].AppName < deployments[j].AppName
+		})
+	} else {
+		deployment, err := apiClient.FindDeploymentByName(args[0])
+		if errors.Is(err, client.ErrDeploymentNotFound) {
+			fmt.Printf("No deployment found with app name: %s\n", args[0])
+			os.Exit(1)
+		}
+		if err != nil {
+			exitWithError("Failed to fetch deployment", err)
+		}
+		deployments = []client.DeploymentResponse{*deployment}
+	}
+
+	if len(deployments) == 0 {
+		fmt.Fprintln(os.Stderr, "No deployments found.")
+		return
+	}
+
+	if exportFormat == "command" {
+		for i := range deployments {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Print(exportCommand(&deployments[i]))
+		}
+		return
+	}
+
+	m := &manifest.Manifest{Version: manifest.CurrentVersion}
+	for i := range deployments {
+		app := manifest.FromDeployment(&deployments[i], exportIncludeSecrets)
+		if exportAll {
+			m.Apps = append(m.Apps, app)
+		} else {
+			m.App = app
+		}
+	}
+
+	data, err := manifest.Marshal(m)
+	if err != nil {
+		fmt.Printf("Error exporting manifest: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(string(data))
+}
+
+// exportCommand renders a deployment as a shell snippet: an env file (when needed) and a deploy command
+func exportCommand(deployment *client.DeploymentResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", deployment.AppName)
+
+	args := []string{"coderun", "deploy", shellQuote(deployment.Image), "--name", shellQuote(deployment.AppName)}
+	args = append(args, "--replicas", fmt.Sprintf("%d", deployment.Replicas))
+	if deployment.CPULimit != "" {
+		args = append(args, "--cpu", shellQuote(deployment.CPULimit))
+	}
+	if deployment.MemoryLimit != "" {
+		args = append(args, "--memory", shellQuote(deployment.MemoryLimit))
+	}
+	if deployment.CPURequest != "" {
+		args = append(args, "--cpu-request", shellQuote(deployment.CPURequest))
+	}
+	if deployment.MemoryRequest != "" {
+		args = append(args, "--memory-request", shellQuote(deployment.MemoryRequest))
+	}
+	if deployment.HTTPPort != nil {
+		args = append(args, "--http-port", fmt.Sprintf("%d", *deployment.HTTPPort))
+	}
+	if deployment.TCPPort != nil {
+		args = append(args, "--tcp-port", fmt.Sprintf("%d", *deployment.TCPPort))
+	}
+	if deployment.PersistentVolumeSize != "" {
+		args = append(args, "--storage-size", shellQuote(deployment.PersistentVolumeSize))
+		args = append(args, "--storage-path", shellQuote(deployment.PersistentVolumeMountPath))
+	}
+
+	if len(deployment.EnvironmentVars) > 0 {
+		envFileName := deployment.AppName + ".env"
+		keys := make([]string, 0, len(deployment.EnvironmentVars))
+		for key := range deployment.EnvironmentVars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintf(&b, "cat > %s <<'EOF'\n", envFileName)
+		for _, key := range keys {
+			value := utils.DotenvQuote(deployment.EnvironmentVars[key])
+			if !exportIncludeSecrets && utils.LooksLikeSecret(key) {
+				value = manifest.Placeholder(key)
+			}
+			fmt.Fprintf(&b, "%s=%s\n", key, value)
+		}
+		fmt.Fprintf(&b, "EOF\n")
+		args = append(args, "--env-file", envFileName)
+	}
+
+	if len(deployment.SecretKeys) > 0 {
+		// Secret values are never returned; the file expands them from the shell at deploy time
+		secretFileName := deployment.AppName + ".secrets.env"
+		keys := append([]string(nil), deployment.SecretKeys...)
+		sort.Strings(keys)
+
+		fmt.Fprintf(&b, "cat > %s <<'EOF'\n", secretFileName)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s=%s\n", key, manifest.Placeholder(key))
+		}
+		fmt.Fprintf(&b, "EOF\n")
+		args = append(args, "--secret-file", secretFileName)
+	}
+
+	fmt.Fprintf(&b, "%s\n", strings.Join(args, " "))
+	return b.String()
+}
+
+// shellQuote quotes a value for a POSIX shell when it contains special characters
+func shellQuote(value string) string {
+	if value != "" && strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:@=+,%", r))
+	}) < 0 {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history APP_NAME",
+	Short: "List the revisions of a deployment",
+	Long: `List the revisions of a deployment: image, resources, a hash of the
+environment variables, author and time. The current revision is marked with *.
+
+Revisions come from the server. For servers without revision support, the
+local journal of specs deployed from this machine (~/.coderun/journal) is used.
+
+Examples:
+  coderun history web-app`,
+	Args: cobra.ExactArgs(1),
+	Run:  runHistory,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) {
+	name := args[0]
+
+	// Create client
+	apiClient, _ := newAPIClient()
+
+	live := findDeploymentOrExit(apiClient, name)
+
+	revisions, local, err := deploymentRevisions(apiClient, live)
+	if err != nil {
+		exitWithError("Error fetching revisions", err)
+	}
+	if len(revisions) == 0 {
+		fmt.Printf("No revisions recorded for %s\n", name)
+		return
+	}
+
+	current := currentRevision(live, revisions, local)
+
+	rows := make([][]string, 0, len(revisions))
+	for _, rev := range revisions {
+		number := strconv.Itoa(rev.Revision)
+		if rev.Revision == current {
+			number += "*"
+		}
+		envHash := rev.EnvHash
+		if envHash == "" {
+			envHash = utils.EnvHash(rev.Spec.EnvironmentVars)
+		}
+		rows = append(rows, []string{
+			number,
+			rev.Spec.Image,
+			strconv.Itoa(rev.Spec.Replicas),
+			valueOrDash(rev.Spec.CPULimit),
+			valueOrDash(rev.Spec.MemoryLimit),
+			envHash,
+			valueOrDash(rev.Author),
+			rev.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	if local {
+		fmt.Printf("Revisions of %s (local journal, server has no revision history)\n\n", name)
+	} else {
+		fmt.Printf("Revisions of %s\n\n", name)
+	}
+	printTable([]string{"REV", "IMAGE", "REPLICAS", "CPU", "MEMORY", "ENV", "AUTHOR", "CREATED"}, rows)
+
+	if local && current == 0 {
+		updated := "unknown"
+		if live.UpdatedAt != nil {
+			updated = live.UpdatedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("\n⚠️  The live deployment (last updated %s) matches no recorded revision; it was probably changed outside this machine\n", updated)
+	}
+}
+
+// findDeploymentOrExit looks up a deployment by app name, exiting when it does not exist
+func findDeploymentOrExit(apiClient *client.Client, name string) *client.DeploymentResponse {
+	live, err := apiClient.FindDeploymentByName(name)
+	if errors.Is(err, client.ErrDeploymentNotFound) {
+		fmt.Printf("No deployment named '%s'\n", name)
+		os.Exit(1)
+	}
+	if err != nil {
+		exitWithError("Error fetching deployment", err)
+	}
+	return live
+}
+
+// deploymentRevisions returns the revisions of a deployment, oldest first, from the server
+// or, when the server has no revision support, from the local journal
+func deploymentRevisions(apiClient *client.Client, live *client.DeploymentResponse) ([]client.Revision, bool, error) {
+	revisionList, err := apiClient.ListRevisions(live.ID)
+	if err == nil {
+		return revisionList.Revisions, false, nil
+	}
+	if !errors.Is(err, client.ErrRevisionsNotSupported) {
+		return nil, false, err
+	}
+
+	entries, err := utils.ReadJournal(live.AppName, live.ID)
+	if err != nil {
+		return nil, true, err
+	}
+	revisions := make([]client.Revision, len(entries))
+	for i, entry := range entries {
+		revisions[i] = entry.Revision
+		// Values redacted in the journal are restored where the live value is unchanged
+		utils.RestoreRedacted(&revisions[i].Spec, live.EnvironmentVars)
+	}
+	return revisions, true, nil
+}
+
+// currentRevision returns the number of the revision the deployment runs, or 0 if unknown.
+// The server's latest revision is current; in the local journal it is the latest one
+// matching the live spec.
+func currentRevision(live *client.DeploymentResponse, revisions []client.Revision, local bool) int {
+	if !local {
+		return revisions[len(revisions)-1].Revision
+	}
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if len(utils.DiffDeployment(live, &revisions[i].Spec)) == 0 {
+			return revisions[i].Revision
+		}
+	}
+	return 0
+}
+
+// recordRevision adds a deployed spec to the local journal. Failures only print a warning.
+func recordRevision(deployment *client.DeploymentResponse, action string) {
+	if _, err := utils.AppendJournal(deployment, action); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record revision in the local journal: %v\n", err)
+	}
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -2,13 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/helmcode/coderun-cli/internal/client"
-	"github.com/helmcode/coderun-cli/internal/utils"
 )
 
 // listCmd represents the list command
@@ -100,27 +98,13 @@ func getConnectionString(deployment *client.DeploymentResponse) string {
 }
 
 func runList(cmd *cobra.Command, args []string) {
-	// Load config
-	config, err := utils.LoadConfig()
-	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
-		os.Exit(1)
-	}
-
-	if config.AccessToken == "" {
-		fmt.Println("Please login first using 'coderun login'")
-		os.Exit(1)
-	}
-
 	// Create client and get deployments
-	apiClient := client.NewClient(config.BaseURL)
-	apiClient.SetToken(config.AccessToken)
+	apiClient, _ := newAPIClient()
 
 	fmt.Println("Fetching deployments...")
 	deploymentList, err := apiClient.ListDeployments()
 	if err != nil {
-		fmt.Printf("Failed to fetch deployments: %v\n", err)
-		os.Exit(1)
+		exitWithError("Failed to fetch deployments", err)
 	}
 
 	if len(deploymentList.Deployments) == 0 {
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -19,16 +24,70 @@ var loginCmd = &cobra.Command{
 	Long: `Login to the CodeRun platform using your email and password.
 This will store an authentication token for subsequent commands.
 
-Example:
-  coderun login`,
-	Run: runLogin,
+For CI and scripts, credentials can be supplied without a terminal:
+  --email with --password-stdin   Read the password from stdin
+  --token or --token-stdin        Use an existing API token (verified before saving)
+
+For single sign-on, authorize the CLI from a browser instead:
+  --web                           Open the authorization page in a browser
+  --device                        Show a code to enter on another device (e.g. over SSH)
+
+Examples:
+  coderun login
+  coderun login --web
+  coderun login --device
+  echo "$CODERUN_PASSWORD" | coderun login --email me@example.com --password-stdin
+  echo "$CODERUN_API_TOKEN" | coderun login --token-stdin`,
+	Args: cobra.NoArgs,
+	Run:  runLogin,
 }
 
+var (
+	loginEmail         string
+	loginPasswordStdin bool
+	loginToken         string
+	loginTokenStdin    bool
+	loginWeb           bool
+	loginDevice        bool
+	loginNoBrowser     bool
+)
+
 func init() {
 	rootCmd.AddCommand(loginCmd)
+	loginCmd.Flags().StringVar(&loginEmail, "email", "", "Account email (skips the email prompt)")
+	loginCmd.Flags().BoolVar(&loginPasswordStdin, "password-stdin", false, "Read the password from stdin")
+	loginCmd.Flags().StringVar(&loginToken, "token", "", "Log in with an existing API token")
+	loginCmd.Flags().BoolVar(&loginTokenStdin, "token-stdin", false, "Read an existing API token from stdin")
+	loginCmd.Flags().BoolVar(&loginWeb, "web", false, "Log in through the browser (single sign-on)")
+	loginCmd.Flags().BoolVar(&loginDevice, "device", false, "Log in with a device code entered on another device")
+	loginCmd.Flags().BoolVar(&loginNoBrowser, "no-browser", false, "With --web, print the authorization URL instead of opening a browser")
 }
 
 func runLogin(cmd *cobra.Command, args []string) {
+	// Validate flag combinations
+	if loginToken != "" && loginTokenStdin {
+		fmt.Println("Cannot specify both --token and --token-stdin")
+		os.Exit(1)
+	}
+	useToken := loginToken != "" || loginTokenStdin
+	if useToken && (loginEmail != "" || loginPasswordStdin) {
+		fmt.Println("Cannot combine --token/--token-stdin with --email/--password-stdin")
+		os.Exit(1)
+	}
+	if loginPasswordStdin && loginEmail == "" {
+		fmt.Println("--password-stdin requires --email")
+		os.Exit(1)
+	}
+	useBrowser := loginWeb || loginDevice
+	if loginWeb && loginDevice {
+		fmt.Println("Cannot specify both --web and --device")
+		os.Exit(1)
+	}
+	if useBrowser && (useToken || loginEmail != "" || loginPasswordStdin) {
+		fmt.Println("Cannot combine --web/--device with other login options")
+		os.Exit(1)
+	}
+
 	// Load current config
 	config, err := utils.LoadConfig()
 	if err != nil {
@@ -36,26 +95,91 @@ func runLogin(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// Prompt for email
-	fmt.Print("Email: ")
-	var email string
-	if _, err := fmt.Scanln(&email); err != nil {
-		fmt.Printf("Error reading email: %v\n", err)
-		os.Exit(1)
+	apiClient := client.NewClient(config.BaseURL)
+
+	if useBrowser {
+		var loginResp *client.LoginResponse
+		if loginWeb {
+			loginResp, err = loginWithBrowser(apiClient)
+		} else {
+			loginResp, err = loginWithDeviceCode(apiClient)
+		}
+		if err != nil {
+			fmt.Printf("Login failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		saveLoginTokens(config, loginResp.AccessToken, loginResp.RefreshToken)
+		fmt.Println("✅ Successfully logged in!")
+		fmt.Printf("Token saved to config file\n")
+		return
 	}
 
-	// Prompt for password (hidden input)
-	fmt.Print("Password: ")
-	passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
-	if err != nil {
-		fmt.Printf("\nError reading password: %v\n", err)
+	if useToken {
+		token := loginToken
+		if loginTokenStdin {
+			token, err = readStdinSecret()
+			if err != nil {
+				fmt.Printf("Error reading token from stdin: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if token == "" {
+			fmt.Println("Token is empty")
+			os.Exit(1)
+		}
+
+		// Verify the token before saving it
+		apiClient.SetToken(token)
+		fmt.Println("Verifying token...")
+		userInfo, err := apiClient.GetUserInfo()
+		if err != nil {
+			fmt.Printf("Token verification failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		saveLoginTokens(config, token, "")
+		fmt.Printf("✅ Successfully logged in as %s!\n", userInfo.Email)
+		fmt.Printf("Token saved to config file\n")
+		return
+	}
+
+	// Interactive prompts need a terminal
+	stdinIsTerminal := term.IsTerminal(int(syscall.Stdin))
+	if !stdinIsTerminal && (loginEmail == "" || !loginPasswordStdin) {
+		fmt.Println("Error: stdin is not a terminal, cannot prompt for credentials")
+		fmt.Println("Use --email with --password-stdin, or --token/--token-stdin for non-interactive login")
 		os.Exit(1)
 	}
-	password := string(passwordBytes)
-	fmt.Println() // Add newline after password input
 
-	// Create client and attempt login
-	apiClient := client.NewClient(config.BaseURL)
+	// Prompt for email
+	email := loginEmail
+	if email == "" {
+		fmt.Print("Email: ")
+		if _, err := fmt.Scanln(&email); err != nil {
+			fmt.Printf("Error reading email: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var password string
+	if loginPasswordStdin {
+		password, err = readStdinSecret()
+		if err != nil {
+			fmt.Printf("Error reading password from stdin: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		// Prompt for password (hidden input)
+		fmt.Print("Password: ")
+		passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
+		if err != nil {
+			fmt.Printf("\nError reading password: %v\n", err)
+			os.Exit(1)
+		}
+		password = string(passwordBytes)
+		fmt.Println() // Add newline after password input
+	}
 
 	fmt.Println("Logging in...")
 	loginResp, err := apiClient.Login(email, password)
@@ -64,13 +188,113 @@ func runLogin(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// Save token to config
-	config.AccessToken = loginResp.AccessToken
+	saveLoginTokens(config, loginResp.AccessToken, loginResp.RefreshToken)
+	fmt.Println("✅ Successfully logged in!")
+	fmt.Printf("Token saved to config file\n")
+}
+
+// loginWithBrowser runs the authorization code flow with PKCE through a loopback listener
+func loginWithBrowser(apiClient *client.Client) (*client.LoginResponse, error) {
+	verifier, challenge, err := client.NewPKCE()
+	if err != nil {
+		return nil, err
+	}
+	state, err := utils.RandomState()
+	if err != nil {
+		return nil, err
+	}
+
+	callback, err := utils.StartCallbackServer(state)
+	if err != nil {
+		return nil, err
+	}
+	defer callback.Close()
+
+	authURL := apiClient.AuthorizationURL(callback.RedirectURI(), state, challenge)
+	if loginNoBrowser {
+		fmt.Println("Open this URL in your browser to log in:")
+		fmt.Printf("  %s\n", authURL)
+	} else {
+		fmt.Println("Opening your browser to log in...")
+		if err := utils.OpenBrowser(authURL); err != nil {
+			fmt.Println("Could not open a browser. Open this URL to log in:")
+		} else {
+			fmt.Println("If the browser did not open, visit:")
+		}
+		fmt.Printf("  %s\n", authURL)
+	}
+
+	fmt.Println("Waiting for authorization...")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	code, err := callback.Wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiClient.ExchangeAuthorizationCode(code, verifier, callback.RedirectURI())
+}
+
+// loginWithDeviceCode runs the device authorization flow, polling until the user approves
+func loginWithDeviceCode(apiClient *client.Client) (*client.LoginResponse, error) {
+	deviceResp, err := apiClient.RequestDeviceCode()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("To log in, visit: %s\n", deviceResp.VerificationURI)
+	fmt.Printf("And enter the code: %s\n", deviceResp.UserCode)
+	if deviceResp.VerificationURIComplete != "" {
+		fmt.Printf("Or open: %s\n", deviceResp.VerificationURIComplete)
+	}
+	fmt.Println("Waiting for authorization...")
+
+	interval := time.Duration(deviceResp.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	expiresIn := time.Duration(deviceResp.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 15 * time.Minute
+	}
+	deadline := time.Now().Add(expiresIn)
+
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		loginResp, err := apiClient.PollDeviceToken(deviceResp.DeviceCode)
+		switch {
+		case err == nil:
+			return loginResp, nil
+		case errors.Is(err, client.ErrAuthorizationPending):
+			continue
+		case errors.Is(err, client.ErrSlowDown):
+			interval += 5 * time.Second
+			continue
+		default:
+			return nil, err
+		}
+	}
+
+	return nil, client.ErrDeviceCodeExpired
+}
+
+// saveLoginTokens stores the access and refresh tokens in the config file
+func saveLoginTokens(config *utils.Config, token, refreshToken string) {
+	config.AccessToken = token
+	config.RefreshToken = refreshToken
 	if err := utils.SaveConfig(config); err != nil {
 		fmt.Printf("Error saving config: %v\n", err)
 		os.Exit(1)
 	}
+}
 
-	fmt.Println("✅ Successfully logged in!")
-	fmt.Printf("Token saved to config file\n")
+// readStdinSecret reads a password or token piped through stdin
+func readStdinSecret() (string, error) {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
 }
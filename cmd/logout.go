@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// logoutCmd represents the logout command
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Logout from CodeRun platform",
+	Long: `Revoke the stored access and refresh tokens on the server (when supported)
+and remove them from the local config file.
+
+Example:
+  coderun logout`,
+	Args: cobra.NoArgs,
+	Run:  runLogout,
+}
+
+func init() {
+	rootCmd.AddCommand(logoutCmd)
+}
+
+func runLogout(cmd *cobra.Command, args []string) {
+	// Load config
+	config, err := utils.LoadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if config.AccessToken == "" {
+		fmt.Println("Not logged in")
+		return
+	}
+
+	// Revoke the tokens on the server; local removal happens regardless
+	apiClient := client.NewClient(config.BaseURL)
+	apiClient.SetToken(config.AccessToken)
+
+	// Revoke the refresh token first: it outlives the access token and could mint new ones
+	if config.RefreshToken != "" {
+		revoked, err := apiClient.RevokeRefreshToken(config.RefreshToken)
+		if err != nil {
+			fmt.Printf("Warning: could not revoke refresh token on the server: %v\n", err)
+		} else if revoked {
+			fmt.Println("Refresh token revoked on the server")
+		}
+	}
+
+	revoked, err := apiClient.Logout()
+	if errors.Is(err, client.ErrSessionExpired) {
+		fmt.Println("Session already expired on the server")
+	} else if err != nil {
+		fmt.Printf("Warning: could not revoke token on the server: %v\n", err)
+	} else if revoked {
+		fmt.Println("Token revoked on the server")
+	}
+
+	config.AccessToken = ""
+	config.RefreshToken = ""
+	if err := utils.SaveConfig(config); err != nil {
+		fmt.Printf("Error saving config: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✅ Successfully logged out!")
+}
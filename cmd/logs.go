@@ -2,11 +2,8 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
-	"github.com/helmcode/coderun-cli/internal/client"
-	"github.com/helmcode/coderun-cli/internal/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -37,27 +34,13 @@ func init() {
 func runLogs(cmd *cobra.Command, args []string) {
 	deploymentID := args[0]
 
-	// Load config
-	config, err := utils.LoadConfig()
-	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
-		os.Exit(1)
-	}
-
-	if config.AccessToken == "" {
-		fmt.Println("Please login first using 'coderun login'")
-		os.Exit(1)
-	}
-
 	// Create client and get logs
-	apiClient := client.NewClient(config.BaseURL)
-	apiClient.SetToken(config.AccessToken)
+	apiClient, _ := newAPIClient()
 
 	fmt.Printf("Fetching logs for deployment %s...\n", deploymentID)
 	logsResponse, err := apiClient.GetDeploymentLogs(deploymentID, logLines)
 	if err != nil {
-		fmt.Printf("Failed to get logs: %v\n", err)
-		os.Exit(1)
+		exitWithError("Failed to get logs", err)
 	}
 
 	// Display deployment info
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// outputMu serializes output from concurrent app rollouts
+var outputMu sync.Mutex
+
+// appLogger prints progress lines, prefixed with the app name when several apps run at once
+type appLogger struct {
+	prefix string
+}
+
+// newAppLogger returns a logger; an empty name prints lines unchanged
+func newAppLogger(name string) *appLogger {
+	if name == "" {
+		return &appLogger{}
+	}
+	return &appLogger{prefix: fmt.Sprintf("[%s] ", name)}
+}
+
+// Printf formats and prints a message, prefixing every line
+func (l *appLogger) Printf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	if l.prefix == "" {
+		fmt.Print(message)
+		return
+	}
+
+	trailingNewline := strings.HasSuffix(message, "\n")
+	lines := strings.Split(strings.TrimSuffix(message, "\n"), "\n")
+	for i, line := range lines {
+		fmt.Print(l.prefix + line)
+		if i < len(lines)-1 || trailingNewline {
+			fmt.Println()
+		}
+	}
+}
+
+// Println prints a message followed by a newline
+func (l *appLogger) Println(message string) {
+	l.Printf("%s\n", message)
+}
+
+// ANSI colors used for diffs
+const (
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+	colorReset = "\033[0m"
+)
+
+// colorEnabled reports whether stdout is a terminal and colors were not disabled with NO_COLOR
+func colorEnabled() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// colorize wraps text in an ANSI color when enabled
+func colorize(enabled bool, color, text string) string {
+	if !enabled {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// confirm asks a yes/no question on the terminal; it answers no when stdin is not a terminal
+func confirm(question string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Println("stdin is not a terminal; use --yes to confirm")
+		return false
+	}
+
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback APP_NAME",
+	Short: "Re-apply an earlier revision of a deployment",
+	Long: `Re-apply the spec of an earlier revision of a deployment.
+
+Without --to, the deployment goes back to the revision before the current one.
+Use 'coderun history' to list revisions.
+
+For servers without revision support, the spec is taken from the local journal
+of specs deployed from this machine.
+
+Examples:
+  coderun rollback web-app
+  coderun rollback web-app --to 3`,
+	Args: cobra.ExactArgs(1),
+	Run:  runRollback,
+}
+
+var rollbackTo int
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+	rollbackCmd.Flags().IntVar(&rollbackTo, "to", 0, "Revision to roll back to (default: the previous revision)")
+}
+
+func runRollback(cmd *cobra.Command, args []string) {
+	name := args[0]
+
+	// Create client
+	apiClient, _ := newAPIClient()
+
+	live := findDeploymentOrExit(apiClient, name)
+
+	revisions, local, err := deploymentRevisions(apiClient, live)
+	if err != nil {
+		exitWithError("Error fetching revisions", err)
+	}
+
+	target := rollbackTarget(live, revisions, local)
+	if target == nil {
+		if rollbackTo > 0 {
+			fmt.Printf("Revision %d of %s not found. Use 'coderun history %s' to list revisions\n", rollbackTo, name, name)
+		} else {
+			fmt.Printf("No earlier revision of %s to roll back to\n", name)
+		}
+		os.Exit(1)
+	}
+
+	// The journal stores secret-looking values as hashes; they can only be rolled back while unchanged
+	if local {
+		if keys := utils.RestoreRedacted(&target.Spec, live.EnvironmentVars); len(keys) > 0 {
+			fmt.Printf("Cannot roll back %s to revision %d: the local journal only stores hashes of %s, whose values changed since.\n", name, target.Revision, strings.Join(keys, ", "))
+			fmt.Println("Store secret-looking values as secrets (--secret) so that rollbacks do not depend on them")
+			os.Exit(1)
+		}
+	}
+
+	changes := utils.DiffDeployment(live, &target.Spec)
+	if len(changes) == 0 {
+		fmt.Printf("✅ %s already runs revision %d\n", name, target.Revision)
+		return
+	}
+
+	fmt.Printf("Rolling back %s to revision %d...\n", name, target.Revision)
+	printChanges(changes)
+
+	var deployment *client.DeploymentResponse
+	if local {
+		deployment, err = apiClient.UpdateDeployment(live.ID, &target.Spec)
+	} else {
+		deployment, err = apiClient.RollbackDeployment(live.ID, target.Revision)
+	}
+	if err != nil {
+		exitIfSessionExpired(err)
+		fmt.Printf("Rollback failed: %s\n", parseValidationError(err.Error()))
+		os.Exit(1)
+	}
+	recordRevision(deployment, "rollback")
+
+	fmt.Printf("✅ Rolled back %s to revision %d\n", name, target.Revision)
+	printDeployment(deployment)
+}
+
+// rollbackTarget returns the revision selected by --to or, by default, the latest revision
+// before the current one that differs from the live spec
+func rollbackTarget(live *client.DeploymentResponse, revisions []client.Revision, local bool) *client.Revision {
+	if rollbackTo > 0 {
+		for i := range revisions {
+			if revisions[i].Revision == rollbackTo {
+				return &revisions[i]
+			}
+		}
+		return nil
+	}
+
+	current := currentRevision(live, revisions, local)
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if current != 0 && revisions[i].Revision >= current {
+			continue
+		}
+		if len(utils.DiffDeployment(live, &revisions[i].Spec)) > 0 {
+			return &revisions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/manifest"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for coderun.yaml manifests",
+	Long: `Print the JSON Schema for coderun.yaml manifests.
+
+Editors with YAML language server support (VS Code, JetBrains, Neovim) use it
+for autocompletion and inline errors. Reference it from a manifest with:
+
+  # yaml-language-server: $schema=` + manifest.SchemaID + `
+
+Examples:
+  coderun schema
+  coderun schema -o coderun.schema.json`,
+	Args: cobra.NoArgs,
+	Run:  runSchema,
+}
+
+var schemaOutput string
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+	schemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "Write the schema to a file instead of stdout")
+}
+
+func runSchema(cmd *cobra.Command, args []string) {
+	data, err := json.MarshalIndent(manifest.Schema(), "", "  ")
+	if err != nil {
+		fmt.Printf("Error generating schema: %v\n", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+
+	if schemaOutput == "" {
+		fmt.Print(string(data))
+		return
+	}
+
+	if err := os.WriteFile(schemaOutput, data, 0644); err != nil {
+		fmt.Printf("Error writing schema: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Schema written to %s\n", schemaOutput)
+}
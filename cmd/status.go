@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -30,27 +30,13 @@ func init() {
 func runStatus(cmd *cobra.Command, args []string) {
 	deploymentID := args[0]
 
-	// Load config
-	config, err := utils.LoadConfig()
-	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
-		os.Exit(1)
-	}
-
-	if config.AccessToken == "" {
-		fmt.Println("Please login first using 'coderun login'")
-		os.Exit(1)
-	}
-
 	// Create client and get status
-	apiClient := client.NewClient(config.BaseURL)
-	apiClient.SetToken(config.AccessToken)
+	apiClient, _ := newAPIClient()
 
 	fmt.Printf("Getting status for deployment '%s'...\n", deploymentID)
 	status, err := apiClient.GetDeploymentStatus(deploymentID)
 	if err != nil {
-		fmt.Printf("Failed to get deployment status: %v\n", err)
-		os.Exit(1)
+		exitWithError("Failed to get deployment status", err)
 	}
 
 	// Display status information
@@ -91,6 +77,11 @@ func runStatus(cmd *cobra.Command, args []string) {
 		fmt.Printf("    Mount Path: %s\n", status.PersistentVolumeMountPath)
 	}
 
+	// Show environment variables; the deployment details are optional
+	if deployment, err := apiClient.GetDeployment(deploymentID); err == nil {
+		printEnvironment(deployment, fetchEnvGroupsOrWarn(apiClient, deployment.EnvGroups))
+	}
+
 	if len(status.Pods) > 0 {
 		fmt.Printf("\n� Pods:\n")
 		for i, pod := range status.Pods {
@@ -102,3 +93,36 @@ func runStatus(cmd *cobra.Command, args []string) {
 		}
 	}
 }
+
+// printEnvironment lists the effective environment with secret values masked.
+// Plain variables whose names look like secrets are masked too. When the app uses
+// env groups, the source of each variable is shown.
+func printEnvironment(deployment *client.DeploymentResponse, groups []*client.EnvGroup) {
+	vars := utils.EffectiveEnv(deployment, groups)
+	if len(vars) == 0 {
+		return
+	}
+
+	fmt.Printf("🔧 Environment:\n")
+	if len(deployment.EnvGroups) > 0 {
+		fmt.Printf("    Groups: %s (app variables override groups, later groups override earlier ones)\n", strings.Join(deployment.EnvGroups, ", "))
+	}
+	for _, v := range vars {
+		value := v.Value
+		if v.Secret {
+			value += " (secret)"
+		} else if utils.LooksLikeSecret(v.Key) {
+			value = utils.MaskedValue
+		}
+
+		if len(deployment.EnvGroups) == 0 {
+			fmt.Printf("    %s=%s\n", v.Key, value)
+			continue
+		}
+		source := v.Source
+		if len(v.Overridden) > 0 {
+			source += fmt.Sprintf(", overrides %s", strings.Join(v.Overridden, ", "))
+		}
+		fmt.Printf("    %s=%s  [%s]\n", v.Key, value, source)
+	}
+}
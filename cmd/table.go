@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// printTable prints rows as left-aligned columns with a dashed separator under the headers,
+// in the same layout as the deployments list
+func printTable(headers []string, rows [][]string) {
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = len(header)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) && len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	formats := make([]string, len(widths))
+	separators := make([]interface{}, len(widths))
+	for i, width := range widths {
+		// Add some padding
+		formats[i] = fmt.Sprintf("%%-%ds", width+2)
+		separators[i] = strings.Repeat("-", width+2)
+	}
+	rowFormat := strings.Join(formats, " ") + "\n"
+
+	fmt.Printf(rowFormat, toInterfaces(headers)...)
+	fmt.Printf(rowFormat, separators...)
+	for _, row := range rows {
+		fmt.Printf(rowFormat, toInterfaces(row)...)
+	}
+}
+
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
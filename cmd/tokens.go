@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+)
+
+// tokensCmd represents the tokens command
+var tokensCmd = &cobra.Command{
+	Use:   "tokens",
+	Short: "Manage personal access tokens",
+	Long: `Manage named, scoped and expiring API tokens for CI jobs and service accounts.
+
+A token is shown only once, when it is created. Use it by setting the
+CODERUN_TOKEN environment variable; no interactive login is needed.
+
+Examples:
+  coderun tokens create github-actions --scope deployments:write --expires-in-days 30
+  coderun tokens list
+  coderun tokens revoke <TOKEN_ID>`,
+}
+
+// tokensCreateCmd represents the tokens create command
+var tokensCreateCmd = &cobra.Command{
+	Use:   "create NAME",
+	Short: "Create a personal access token",
+	Args:  cobra.ExactArgs(1),
+	Run:   runTokensCreate,
+}
+
+// tokensListCmd represents the tokens list command
+var tokensListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List personal access tokens",
+	Args:  cobra.NoArgs,
+	Run:   runTokensList,
+}
+
+// tokensRevokeCmd represents the tokens revoke command
+var tokensRevokeCmd = &cobra.Command{
+	Use:   "revoke TOKEN_ID",
+	Short: "Revoke a personal access token",
+	Args:  cobra.ExactArgs(1),
+	Run:   runTokensRevoke,
+}
+
+var (
+	tokenScopes        []string
+	tokenExpiresInDays int
+)
+
+func init() {
+	rootCmd.AddCommand(tokensCmd)
+	tokensCmd.AddCommand(tokensCreateCmd)
+	tokensCmd.AddCommand(tokensListCmd)
+	tokensCmd.AddCommand(tokensRevokeCmd)
+
+	tokensCreateCmd.Flags().StringArrayVar(&tokenScopes, "scope", nil, "Scope granted to the token (repeatable, e.g. deployments:read)")
+	tokensCreateCmd.Flags().IntVar(&tokenExpiresInDays, "expires-in-days", 90, "Days until the token expires (0 for no expiry)")
+}
+
+func runTokensCreate(cmd *cobra.Command, args []string) {
+	name := args[0]
+
+	if tokenExpiresInDays < 0 {
+		fmt.Println("--expires-in-days cannot be negative")
+		os.Exit(1)
+	}
+
+	// Create client
+	apiClient, _ := newAPIClient()
+
+	tokenReq := client.APITokenCreate{
+		Name:          name,
+		Scopes:        tokenScopes,
+		ExpiresInDays: &tokenExpiresInDays,
+	}
+
+	fmt.Printf("Creating token '%s'...\n", name)
+	tokenResp, err := apiClient.CreateAPIToken(&tokenReq)
+	if err != nil {
+		exitWithError("Failed to create token", err)
+	}
+
+	fmt.Println("✅ Token created successfully!")
+	fmt.Printf("Token ID: %s\n", tokenResp.ID)
+	fmt.Printf("Name: %s\n", tokenResp.Name)
+	if len(tokenResp.Scopes) > 0 {
+		fmt.Printf("Scopes: %s\n", strings.Join(tokenResp.Scopes, ", "))
+	}
+	if tokenResp.ExpiresAt != nil {
+		fmt.Printf("Expires: %s\n", tokenResp.ExpiresAt.Format("2006-01-02 15:04"))
+	} else {
+		fmt.Printf("Expires: never\n")
+	}
+	fmt.Printf("\n%s\n\n", tokenResp.Token)
+	fmt.Println("⚠️  Copy this token now, it will not be shown again.")
+	fmt.Println("Use it with: export CODERUN_TOKEN=<token>")
+}
+
+func runTokensList(cmd *cobra.Command, args []string) {
+	// Create client
+	apiClient, _ := newAPIClient()
+
+	tokenList, err := apiClient.ListAPITokens()
+	if err != nil {
+		exitWithError("Failed to fetch tokens", err)
+	}
+
+	if len(tokenList.Tokens) == 0 {
+		fmt.Println("No tokens found.")
+		return
+	}
+
+	rows := make([][]string, 0, len(tokenList.Tokens))
+	for _, token := range tokenList.Tokens {
+		expires := "never"
+		if token.ExpiresAt != nil {
+			expires = token.ExpiresAt.Format("2006-01-02 15:04")
+		}
+		lastUsed := "never"
+		if token.LastUsedAt != nil {
+			lastUsed = token.LastUsedAt.Format("2006-01-02 15:04")
+		}
+		scopes := strings.Join(token.Scopes, ",")
+		if scopes == "" {
+			scopes = "all"
+		}
+
+		rows = append(rows, []string{
+			token.ID,
+			token.Name,
+			token.Prefix,
+			scopes,
+			token.CreatedAt.Format("2006-01-02 15:04"),
+			expires,
+			lastUsed,
+		})
+	}
+
+	printTable([]string{"ID", "Name", "Prefix", "Scopes", "Created", "Expires", "Last Used"}, rows)
+}
+
+func runTokensRevoke(cmd *cobra.Command, args []string) {
+	tokenID := args[0]
+
+	// Create client
+	apiClient, _ := newAPIClient()
+
+	fmt.Printf("Revoking token %s...\n", tokenID)
+	if err := apiClient.RevokeAPIToken(tokenID); err != nil {
+		exitWithError("Failed to revoke token", err)
+	}
+
+	fmt.Printf("✅ Token %s revoked successfully!\n", tokenID)
+}
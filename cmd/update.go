@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update APP_NAME",
+	Short: "Update an existing deployment in place",
+	Long: `Update an existing deployment in place with a rolling update.
+
+Only the given flags are changed; everything else, including the app URL, is kept.
+--env-file and --env are merged like in deploy and replace all plain environment
+variables of the app; secrets are kept. To change single variables, use 'coderun env set'.
+
+Examples:
+  coderun update web-app --image my-app:v1.3.0
+  coderun update web-app --memory 1Gi --cpu 500m
+  coderun update web-app --replicas 3 --env-file base.env --env-file production.env --env LOG_LEVEL=debug`,
+	Args: cobra.ExactArgs(1),
+	Run:  runUpdate,
+}
+
+var (
+	updateImage         string
+	updateReplicas      int
+	updateCPU           string
+	updateMemory        string
+	updateCPURequest    string
+	updateMemoryRequest string
+	updateEnvFiles      []string
+	updateEnvFormat     string
+	updateEnvOverrides  []string
+)
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	updateCmd.Flags().StringVar(&updateImage, "image", "", "New container image")
+	updateCmd.Flags().IntVar(&updateReplicas, "replicas", 1, "New number of replicas")
+	updateCmd.Flags().StringVar(&updateCPU, "cpu", "", "New CPU limit (e.g., 100m, 0.5)")
+	updateCmd.Flags().StringVar(&updateMemory, "memory", "", "New memory limit (e.g., 128Mi, 1Gi)")
+	updateCmd.Flags().StringVar(&updateCPURequest, "cpu-request", "", "New CPU request")
+	updateCmd.Flags().StringVar(&updateMemoryRequest, "memory-request", "", "New memory request")
+	updateCmd.Flags().StringArrayVar(&updateEnvFiles, "env-file", nil, "Environment file replacing all plain variables (repeatable, later files win, '-' for stdin)")
+	updateCmd.Flags().StringVar(&updateEnvFormat, "env-format", utils.EnvFormatAuto, "Format of --env-file: auto, dotenv, json or yaml")
+	updateCmd.Flags().StringArrayVar(&updateEnvOverrides, "env", nil, "Environment variable as KEY=VALUE, or KEY to take it from the local environment (repeatable, wins over files)")
+}
+
+func runUpdate(cmd *cobra.Command, args []string) {
+	name := args[0]
+	flags := cmd.Flags()
+
+	update := &client.DeploymentUpdate{}
+	if flags.Changed("image") {
+		update.Image = &updateImage
+	}
+	if flags.Changed("replicas") {
+		update.Replicas = &updateReplicas
+	}
+	if flags.Changed("cpu") {
+		update.CPULimit = &updateCPU
+	}
+	if flags.Changed("memory") {
+		update.MemoryLimit = &updateMemory
+	}
+	if flags.Changed("cpu-request") {
+		update.CPURequest = &updateCPURequest
+	}
+	if flags.Changed("memory-request") {
+		update.MemoryRequest = &updateMemoryRequest
+	}
+	if len(updateEnvFiles) > 0 || len(updateEnvOverrides) > 0 {
+		envVars, sources, err := utils.MergeEnv(updateEnvFiles, updateEnvFormat, updateEnvOverrides, "--env")
+		if err != nil {
+			fmt.Printf("Error loading environment variables: %v\n", err)
+			os.Exit(1)
+		}
+		// An empty result would be dropped from the request and silently change nothing
+		if len(envVars) == 0 {
+			fmt.Println("The env files define no variables. Use 'coderun env unset' to remove variables from an app")
+			os.Exit(1)
+		}
+		fmt.Printf("Loaded %d environment variables:\n", len(sources))
+		printEnvSources(sources)
+		warnSecretRefs(envVars, "store it with 'coderun env set APP --secret'")
+		update.EnvironmentVars = envVars
+	}
+
+	if update.IsEmpty() {
+		fmt.Println("Nothing to update. Specify at least one of --image, --replicas, --cpu, --memory, --cpu-request, --memory-request, --env-file or --env")
+		os.Exit(1)
+	}
+
+	// Create client
+	apiClient, _ := newAPIClient()
+
+	live, err := apiClient.FindDeploymentByName(name)
+	if errors.Is(err, client.ErrDeploymentNotFound) {
+		fmt.Printf("No deployment named '%s'. Use 'coderun deploy' to create it\n", name)
+		os.Exit(1)
+	}
+	if err != nil {
+		exitWithError("Error fetching deployment", err)
+	}
+
+	// Validate the resulting spec before sending anything
+	desired := live.Spec()
+	update.ApplyTo(desired)
+	if err := utils.ValidateDeploymentSpec(desired); err != nil {
+		fmt.Printf("Invalid update: %v\n", err)
+		os.Exit(1)
+	}
+
+	changes := utils.DiffDeployment(live, desired)
+	if len(changes) == 0 {
+		fmt.Printf("✅ %s is already up to date\n", name)
+		return
+	}
+
+	fmt.Printf("Updating %s...\n", name)
+	printChanges(changes)
+
+	deployment, err := apiClient.PatchDeployment(live.ID, update)
+	if err != nil {
+		exitIfSessionExpired(err)
+		fmt.Printf("Update failed: %s\n", parseValidationError(err.Error()))
+		os.Exit(1)
+	}
+	recordRevision(deployment, "updated")
+
+	fmt.Println("✅ Deployment updated successfully!")
+	printDeployment(deployment)
+}
+
+// printChanges lists the fields changed by an update; environment values stay masked
+func printChanges(changes []utils.FieldChange) {
+	for _, change := range changes {
+		switch change.Type {
+		case utils.ChangeAdded:
+			fmt.Printf("  + %s: %s\n", change.Field, change.Desired)
+		case utils.ChangeRemoved:
+			fmt.Printf("  - %s: %s\n", change.Field, change.Live)
+		default:
+			fmt.Printf("  ~ %s: %s -> %s\n", change.Field, change.Live, change.Desired)
+		}
+	}
+}
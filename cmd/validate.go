@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/manifest"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate [FILE...]",
+	Short: "Check manifests without contacting the server",
+	Long: `Check one or more manifests without contacting the server.
+
+Runs every check that apply performs locally: manifest structure and unknown
+keys, app names, resource quantities, port ranges, HTTP/TCP exclusivity,
+storage settings, dependencies, env files and build contexts.
+
+Exits with status 1 if any manifest is invalid, so it can be used in CI or as
+a pre-commit hook.
+
+Examples:
+  coderun validate
+  coderun validate deploy/coderun.yaml stack.yaml
+  coderun validate --overlay staging --var IMAGE_TAG=v1.4.2`,
+	Run: runValidate,
+}
+
+var (
+	validateOverlay string
+	validateVars    []string
+)
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().StringVar(&validateOverlay, "overlay", "", "Environment overlay to merge before validating")
+	validateCmd.Flags().StringArrayVar(&validateVars, "var", nil, "Variable for ${VAR} interpolation as KEY=VALUE (repeatable)")
+}
+
+func runValidate(cmd *cobra.Command, args []string) {
+	files := args
+	if len(files) == 0 {
+		files = []string{manifest.DefaultFile}
+	}
+
+	failed := 0
+	for _, file := range files {
+		if err := validateManifest(file); err != nil {
+			fmt.Printf("❌ %s\n%v\n", file, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %s\n", file)
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n%d of %d manifest(s) invalid\n", failed, len(files))
+		os.Exit(1)
+	}
+}
+
+// validateManifest loads a manifest and checks the files it references
+func validateManifest(file string) error {
+	m, err := manifest.Load(file, manifestOptions(file, validateOverlay, validateVars))
+	if err != nil {
+		return err
+	}
+
+	for _, app := range m.AllApps() {
+		spec, err := app.DeploymentCreate(m.Dir)
+		if err != nil {
+			return fmt.Errorf("%s: %v", app.Name, err)
+		}
+		for _, key := range plainSecretRefs(spec.EnvironmentVars) {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s holds a secret reference but is a plain variable, so it is not resolved; move it to secrets\n", app.Name, key)
+		}
+		if app.EnvSchema != nil {
+			// Secret references are resolved at deploy time, so only their presence is checked here
+			isReference := func(key, value string) bool {
+				_, secret := spec.SecretVars[key]
+				return secret && secretRegistry().IsReference(value)
+			}
+			if err := checkEnvSchema(app.EnvSchema, isReference, spec.EnvironmentVars, spec.SecretVars); err != nil {
+				return fmt.Errorf("%s: environment does not match env_schema:\n%v", app.Name, err)
+			}
+		}
+		for _, key := range utils.SecretLookingKeys(spec.EnvironmentVars) {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s looks like a secret but is a plain variable; move it to secrets\n", app.Name, key)
+		}
+		if app.Build != nil {
+			info, err := os.Stat(app.BuildContext(m.Dir))
+			if err != nil || !info.IsDir() {
+				return fmt.Errorf("%s: build context %s is not a directory", app.Name, app.BuildContext(m.Dir))
+			}
+		}
+	}
+
+	return nil
+}
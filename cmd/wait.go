@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+)
+
+// restartLoopThreshold is the number of restarts after which a pod is considered crash-looping
+const restartLoopThreshold = 3
+
+// waitLogLines is the number of log lines shown for crash-looping pods
+const waitLogLines = 30
+
+// waitForReadyOrExit waits until a deployment is ready, exiting with its recent logs when it fails
+func waitForReadyOrExit(apiClient *client.Client, deployment *client.DeploymentResponse, timeout time.Duration) {
+	fmt.Printf("\nWaiting for %s to be ready (timeout %s)...\n", deployment.AppName, timeout)
+	if err := waitForDeployment(apiClient, deployment, timeout); err != nil {
+		exitIfSessionExpired(err)
+		fmt.Printf("❌ %s is not ready: %v\n", deployment.AppName, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ %s is ready\n", deployment.AppName)
+}
+
+// waitForDeployment polls until all replicas are ready, the TLS certificate is issued for HTTP apps
+// and the URL answers without a server error. It prints pod progress as it changes and fails early
+// when a pod is stuck in a restart loop.
+func waitForDeployment(apiClient *client.Client, deployment *client.DeploymentResponse, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	progress := &waitProgress{pods: make(map[string]string)}
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	var pending string
+
+	_, err := apiClient.WaitForDeploymentReady(ctx, deployment.ID, client.DeploymentWaitOptions{
+		OnProgress: progress.update,
+		Condition: func(status *client.DeploymentStatus) (bool, error) {
+			if status.Status == client.DeploymentStatusFailed {
+				return false, fmt.Errorf("deployment failed")
+			}
+			if err := checkRestartLoop(apiClient, deployment.ID); err != nil {
+				return false, err
+			}
+			pending = pendingCondition(httpClient, deployment, status)
+			return pending == "", nil
+		},
+	})
+
+	var timeoutErr *client.WaitTimeoutError
+	if errors.As(err, &timeoutErr) && pending != "" {
+		return fmt.Errorf("timed out after %s waiting for %s", timeout, pending)
+	}
+	return err
+}
+
+// pendingCondition describes what the deployment is still waiting for, or returns "" when it is ready
+func pendingCondition(httpClient *http.Client, deployment *client.DeploymentResponse, status *client.DeploymentStatus) string {
+	if status.ReplicasDesired == 0 || status.ReplicasReady < status.ReplicasDesired {
+		return fmt.Sprintf("replicas (%d/%d ready)", status.ReplicasReady, status.ReplicasDesired)
+	}
+	if deployment.HTTPPort == nil {
+		return ""
+	}
+
+	if status.TLSCertificate != nil && !status.TLSCertificate.Ready {
+		return fmt.Sprintf("TLS certificate (%s)", status.TLSCertificate.Status)
+	}
+
+	url := status.URL
+	if url == nil {
+		url = deployment.URL
+	}
+	if url == nil {
+		return ""
+	}
+	resp, err := httpClient.Get(*url)
+	if err != nil {
+		return fmt.Sprintf("%s to respond (%v)", *url, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Sprintf("%s to respond without a server error (HTTP %d)", *url, resp.StatusCode)
+	}
+	return ""
+}
+
+// checkRestartLoop returns an error with the recent logs of pods that keep restarting
+func checkRestartLoop(apiClient *client.Client, deploymentID string) error {
+	logs, err := apiClient.GetDeploymentLogs(deploymentID, waitLogLines)
+	if err != nil {
+		// Logs are best effort; readiness is decided by the status
+		return nil
+	}
+
+	var looping []string
+	for podName, pod := range logs.Logs {
+		if pod.RestartCount >= restartLoopThreshold || strings.Contains(pod.Status, "CrashLoopBackOff") {
+			looping = append(looping, podName)
+		}
+	}
+	if len(looping) == 0 {
+		return nil
+	}
+	sort.Strings(looping)
+
+	for _, podName := range looping {
+		pod := logs.Logs[podName]
+		fmt.Printf("\n📋 Recent logs of %s (%d restarts):\n", podName, pod.RestartCount)
+		fmt.Println("================")
+		if pod.Logs == "" {
+			fmt.Println("No logs available")
+		} else {
+			fmt.Println(strings.TrimRight(pod.Logs, "\n"))
+		}
+		fmt.Println("================")
+	}
+
+	return fmt.Errorf("restart loop detected in %s", strings.Join(looping, ", "))
+}
+
+// waitProgress prints deployment progress lines when they change
+type waitProgress struct {
+	replicas string
+	tls      string
+	pods     map[string]string
+}
+
+func (p *waitProgress) update(status *client.DeploymentStatus) {
+	replicas := fmt.Sprintf("%d/%d", status.ReplicasReady, status.ReplicasDesired)
+	if replicas != p.replicas {
+		fmt.Printf("  Replicas ready: %s\n", replicas)
+		p.replicas = replicas
+	}
+
+	for i, pod := range status.Pods {
+		name := pod["name"]
+		if name == "" {
+			name = "pod-" + strconv.Itoa(i+1)
+		}
+		state := podState(pod)
+		if p.pods[name] != state {
+			fmt.Printf("  Pod %s: %s\n", name, state)
+			p.pods[name] = state
+		}
+	}
+
+	if status.TLSCertificate != nil {
+		tls := status.TLSCertificate.Status
+		if status.TLSCertificate.Ready {
+			tls = "ready"
+		}
+		if tls != p.tls {
+			fmt.Printf("  TLS certificate: %s\n", tls)
+			p.tls = tls
+		}
+	}
+}
+
+// podState summarizes a pod entry from the deployment status
+func podState(pod map[string]string) string {
+	var parts []string
+	if status := pod["status"]; status != "" {
+		parts = append(parts, status)
+	}
+	if ready := pod["ready"]; ready != "" {
+		parts = append(parts, "ready: "+ready)
+	}
+	if restarts := pod["restarts"]; restarts != "" && restarts != "0" {
+		parts = append(parts, "restarts: "+restarts)
+	}
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// whoamiCmd represents the whoami command
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show the current account",
+	Long: `Show the account you are logged in with, its namespace, the context in use
+(where the credentials come from and which API they are sent to) and when the
+token expires.
+
+Example:
+  coderun whoami`,
+	Args: cobra.NoArgs,
+	Run:  runWhoami,
+}
+
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+}
+
+func runWhoami(cmd *cobra.Command, args []string) {
+	// Create client and get user info
+	apiClient, config := newAPIClient()
+
+	userInfo, err := apiClient.GetUserInfo()
+	if err != nil {
+		exitWithError("Failed to get user info", err)
+	}
+
+	configPath, _ := utils.GetConfigPath()
+
+	fmt.Printf("👤 Account: %s\n", userInfo.Email)
+	fmt.Printf("Namespace: %s\n", userInfo.Namespace)
+	if !userInfo.IsActive {
+		fmt.Printf("Active: no\n")
+	}
+	fmt.Printf("Member Since: %s\n", userInfo.CreatedAt.Format("2006-01-02"))
+
+	// The context is where the credentials come from: CODERUN_TOKEN or the stored login
+	if utils.GetEnvToken() != "" {
+		fmt.Printf("Context: CODERUN_TOKEN (environment)\n")
+	} else {
+		fmt.Printf("Context: default (%s)\n", configPath)
+	}
+	fmt.Printf("API URL: %s\n", config.BaseURL)
+
+	if expiry, ok := utils.TokenExpiry(apiClient.Token); ok {
+		remaining := time.Until(expiry).Round(time.Minute)
+		if remaining > 0 {
+			fmt.Printf("Token Expires: %s (in %s)\n", expiry.Local().Format("2006-01-02 15:04:05"), remaining)
+		} else {
+			fmt.Printf("Token Expires: %s (expired)\n", expiry.Local().Format("2006-01-02 15:04:05"))
+		}
+	} else {
+		fmt.Printf("Token Expires: unknown\n")
+	}
+}
@@ -5,8 +5,11 @@ go 1.23.0
 toolchain go1.24.4
 
 require (
+	filippo.io/age v1.2.1
 	github.com/spf13/cobra v1.9.1
+	golang.org/x/crypto v0.24.0
 	golang.org/x/term v0.33.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -31,6 +32,43 @@ func (c *Client) Login(email, password string) (*LoginResponse, error) {
 	return &loginResp, nil
 }
 
+// requestRefresh exchanges a refresh token for new tokens.
+// It sends the request without an access token so it never triggers another refresh.
+func (c *Client) requestRefresh(refreshToken string) (*LoginResponse, error) {
+	jsonBody, err := json.Marshal(RefreshRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	resp, err := c.send(func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", c.BaseURL+"/api/v1/auth/refresh", bytes.NewReader(jsonBody))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Only a rejected refresh token means the session is over; other failures may be transient
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, ErrSessionExpired
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleAPIError(resp)
+	}
+
+	var loginResp LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&loginResp); err != nil {
+		return nil, fmt.Errorf("failed to decode refresh response: %w", err)
+	}
+
+	return &loginResp, nil
+}
+
 // GetUserInfo gets current user information
 func (c *Client) GetUserInfo() (*UserInfo, error) {
 	resp, err := c.makeRequest("GET", "/api/v1/users/me", nil)
@@ -50,3 +88,111 @@ func (c *Client) GetUserInfo() (*UserInfo, error) {
 
 	return &userInfo, nil
 }
+
+// Logout revokes the current token on the server.
+// It returns false without an error when the server does not support revocation.
+func (c *Client) Logout() (bool, error) {
+	resp, err := c.makeRequest("POST", "/api/v1/auth/logout", nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return true, nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return false, nil
+	}
+
+	return false, handleAPIError(resp)
+}
+
+// RevokeRefreshToken revokes a refresh token on the server so it can no longer be exchanged.
+// Like requestRefresh it is sent without an access token, which may already have expired.
+// It returns false without an error when the server does not support revocation.
+func (c *Client) RevokeRefreshToken(refreshToken string) (bool, error) {
+	jsonBody, err := json.Marshal(RevokeRequest{Token: refreshToken, TokenTypeHint: "refresh_token"})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	resp, err := c.send(func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", c.BaseURL+"/api/v1/auth/revoke", bytes.NewReader(jsonBody))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, "")
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return true, nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return false, nil
+	}
+
+	return false, handleAPIError(resp)
+}
+
+// CreateAPIToken creates a personal access token. The token value is only returned once.
+func (c *Client) CreateAPIToken(tokenReq *APITokenCreate) (*APITokenCreateResponse, error) {
+	resp, err := c.makeRequest("POST", "/api/v1/auth/tokens", tokenReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, handleAPIError(resp)
+	}
+
+	var tokenResp APITokenCreateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	return &tokenResp, nil
+}
+
+// ListAPITokens lists the personal access tokens of the current user
+func (c *Client) ListAPITokens() (*APITokenList, error) {
+	resp, err := c.makeRequest("GET", "/api/v1/auth/tokens", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleAPIError(resp)
+	}
+
+	var tokenList APITokenList
+	if err := json.NewDecoder(resp.Body).Decode(&tokenList); err != nil {
+		return nil, fmt.Errorf("failed to decode token list: %w", err)
+	}
+
+	return &tokenList, nil
+}
+
+// RevokeAPIToken revokes a personal access token by ID
+func (c *Client) RevokeAPIToken(tokenID string) error {
+	endpoint := fmt.Sprintf("/api/v1/auth/tokens/%s", tokenID)
+
+	resp, err := c.makeRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return handleAPIError(resp)
+	}
+
+	return nil
+}
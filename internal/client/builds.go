@@ -49,22 +49,18 @@ func (c *Client) CreateBuild(contextPath, appName, dockerfilePath string) (*Buil
 		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
 	}
 
-	// Create the HTTP request
-	req, err := http.NewRequest("POST", c.BaseURL+"/api/v1/builds/upload", &body)
+	// Make the request; the form is kept in memory so it can be resent after a token refresh
+	formData := body.Bytes()
+	resp, err := c.do(func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", c.BaseURL+"/api/v1/builds/upload", bytes.NewReader(formData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	if c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
-	}
-
-	// Make the request
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -3,17 +3,38 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
+// ErrSessionExpired is returned when the server rejects the token and it cannot be refreshed
+var ErrSessionExpired = errors.New("session expired")
+
 // Client represents the API client
 type Client struct {
 	BaseURL    string
 	HTTPClient *http.Client
 	Token      string
+
+	// RefreshToken, when set, is used to obtain a new access token after a 401
+	RefreshToken string
+	// OnTokenRefresh is called with the new tokens after a successful refresh
+	OnTokenRefresh func(*LoginResponse)
+
+	// mu guards the tokens; refreshing is the refresh in flight, shared by concurrent requests
+	mu         sync.Mutex
+	refreshing *refreshCall
+}
+
+// refreshCall is a token refresh in progress; done is closed once token and err are set
+type refreshCall struct {
+	done  chan struct{}
+	token string
+	err   error
 }
 
 // NewClient creates a new API client
@@ -38,29 +59,92 @@ func NewClientWithTimeout(baseURL string, timeout time.Duration) *Client {
 
 // SetToken sets the authentication token
 func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.Token = token
 }
 
+// SetRefreshToken sets the token used to renew an expired access token
+func (c *Client) SetRefreshToken(refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.RefreshToken = refreshToken
+}
+
+// currentToken returns the access token in use
+func (c *Client) currentToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Token
+}
+
 // makeRequest makes an HTTP request with authentication
 func (c *Client) makeRequest(method, endpoint string, body interface{}) (*http.Response, error) {
-	var bodyReader io.Reader
+	var jsonBody []byte
 
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		var err error
+		jsonBody, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		bodyReader = bytes.NewBuffer(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, c.BaseURL+endpoint, bodyReader)
+	return c.do(func() (*http.Request, error) {
+		var bodyReader io.Reader
+		if jsonBody != nil {
+			bodyReader = bytes.NewReader(jsonBody)
+		}
+
+		req, err := http.NewRequest(method, c.BaseURL+endpoint, bodyReader)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+}
+
+// do sends a request built by newRequest, refreshing the token and retrying once on a 401
+func (c *Client) do(newRequest func() (*http.Request, error)) (*http.Response, error) {
+	token := c.currentToken()
+
+	resp, err := c.send(newRequest, token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || token == "" {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	// The token was rejected: try to refresh it once
+	newToken, err := c.refresh(token)
+	if errors.Is(err, ErrSessionExpired) {
+		return nil, ErrSessionExpired
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh session: %w", err)
+	}
+
+	resp, err = c.send(newRequest, newToken)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return nil, ErrSessionExpired
+	}
+
+	return resp, nil
+}
+
+// send builds and executes a single request with the given token
+func (c *Client) send(newRequest func() (*http.Request, error), token string) (*http.Response, error) {
+	req, err := newRequest()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	if c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
 
 	resp, err := c.HTTPClient.Do(req)
@@ -71,6 +155,60 @@ func (c *Client) makeRequest(method, endpoint string, body interface{}) (*http.R
 	return resp, nil
 }
 
+// refresh exchanges the refresh token for a new access token.
+// If another request already refreshed the rejected token, the current token is reused, and
+// concurrent callers share a single refresh. The lock is not held during the request or the
+// OnTokenRefresh callback, so other requests are never blocked behind the network.
+func (c *Client) refresh(rejectedToken string) (string, error) {
+	c.mu.Lock()
+	if c.Token != rejectedToken {
+		token := c.Token
+		c.mu.Unlock()
+		return token, nil
+	}
+	if call := c.refreshing; call != nil {
+		c.mu.Unlock()
+		<-call.done
+		return call.token, call.err
+	}
+	if c.RefreshToken == "" {
+		c.mu.Unlock()
+		return "", ErrSessionExpired
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	c.refreshing = call
+	refreshToken := c.RefreshToken
+	c.mu.Unlock()
+
+	// The call stays in flight until the callback returns, so refreshed tokens are saved in order
+	defer func() {
+		c.mu.Lock()
+		c.refreshing = nil
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	loginResp, err := c.requestRefresh(refreshToken)
+	if err != nil {
+		call.err = err
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.Token = loginResp.AccessToken
+	if loginResp.RefreshToken != "" {
+		c.RefreshToken = loginResp.RefreshToken
+	}
+	c.mu.Unlock()
+
+	if c.OnTokenRefresh != nil {
+		c.OnTokenRefresh(loginResp)
+	}
+
+	call.token = loginResp.AccessToken
+	return call.token, nil
+}
+
 // handleAPIError handles API error responses
 func handleAPIError(resp *http.Response) error {
 	body, err := io.ReadAll(resp.Body)
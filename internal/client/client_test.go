@@ -0,0 +1,192 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRefreshFailures(t *testing.T) {
+	tests := []struct {
+		name          string
+		refreshStatus int
+		wantExpired   bool
+	}{
+		{"refresh token rejected", http.StatusUnauthorized, true},
+		{"refresh token forbidden", http.StatusForbidden, true},
+		{"server error", http.StatusBadGateway, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/v1/auth/refresh", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.refreshStatus)
+				json.NewEncoder(w).Encode(map[string]string{"detail": "no"})
+			})
+			mux.HandleFunc("/api/v1/users/me", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			c := NewClient(server.URL)
+			c.SetToken("expired")
+			c.SetRefreshToken("refresh")
+
+			_, err := c.GetUserInfo()
+			if err == nil {
+				t.Fatal("GetUserInfo succeeded")
+			}
+			if got := errors.Is(err, ErrSessionExpired); got != tt.wantExpired {
+				t.Fatalf("errors.Is(%v, ErrSessionExpired) = %v, want %v", err, got, tt.wantExpired)
+			}
+		})
+	}
+}
+
+func TestRefreshRetriesOnce(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/auth/refresh", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(LoginResponse{AccessToken: "fresh", RefreshToken: "refresh-2"})
+	})
+	mux.HandleFunc("/api/v1/users/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(UserInfo{Email: "dev@example.com"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var refreshed *LoginResponse
+	c := NewClient(server.URL)
+	c.SetToken("expired")
+	c.SetRefreshToken("refresh")
+	c.OnTokenRefresh = func(resp *LoginResponse) { refreshed = resp }
+
+	user, err := c.GetUserInfo()
+	if err != nil {
+		t.Fatalf("GetUserInfo: %v", err)
+	}
+	if user.Email != "dev@example.com" {
+		t.Fatalf("email = %q", user.Email)
+	}
+	if refreshed == nil || c.RefreshToken != "refresh-2" {
+		t.Fatalf("tokens were not updated: callback %v, refresh token %q", refreshed, c.RefreshToken)
+	}
+}
+
+func TestRevokeRefreshToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		wantRevoked bool
+		wantErr     bool
+	}{
+		{"revoked", http.StatusOK, true, false},
+		{"no content", http.StatusNoContent, true, false},
+		{"not supported", http.StatusNotFound, false, false},
+		{"server error", http.StatusInternalServerError, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got RevokeRequest
+			var authorization string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/auth/revoke" {
+					http.NotFound(w, r)
+					return
+				}
+				authorization = r.Header.Get("Authorization")
+				json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL)
+			c.SetToken("access")
+			revoked, err := c.RevokeRefreshToken("refresh")
+			if (err != nil) != tt.wantErr || revoked != tt.wantRevoked {
+				t.Fatalf("RevokeRefreshToken = %v, %v; want %v, error %v", revoked, err, tt.wantRevoked, tt.wantErr)
+			}
+			if got.Token != "refresh" || got.TokenTypeHint != "refresh_token" {
+				t.Fatalf("request = %+v", got)
+			}
+			if authorization != "" {
+				t.Fatalf("revocation sent the access token: %q", authorization)
+			}
+		})
+	}
+}
+
+func TestRefreshConcurrent(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		refreshes int
+	)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/auth/refresh", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		refreshes++
+		if refreshes == 1 {
+			close(started)
+		}
+		mu.Unlock()
+		<-release
+		json.NewEncoder(w).Encode(LoginResponse{AccessToken: "fresh"})
+	})
+	mux.HandleFunc("/api/v1/users/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(UserInfo{Email: "dev@example.com"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var callbacks int
+	c := NewClient(server.URL)
+	c.SetToken("expired")
+	c.SetRefreshToken("refresh")
+	c.OnTokenRefresh = func(*LoginResponse) { callbacks++ }
+
+	const requests = 5
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		go func() {
+			_, err := c.GetUserInfo()
+			errs <- err
+		}()
+	}
+
+	// While the refresh is in flight the client is not locked
+	<-started
+	unlocked := make(chan string)
+	go func() { unlocked <- c.currentToken() }()
+	select {
+	case <-unlocked:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the client stayed locked during the refresh request")
+	}
+	close(release)
+
+	for i := 0; i < requests; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("GetUserInfo: %v", err)
+		}
+	}
+	if refreshes != 1 || callbacks != 1 {
+		t.Fatalf("%d refreshes and %d callbacks, want 1 of each", refreshes, callbacks)
+	}
+}
@@ -2,10 +2,14 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 )
 
+// ErrDeploymentNotFound is returned when no deployment matches an app name
+var ErrDeploymentNotFound = errors.New("deployment not found")
+
 // CreateDeployment creates a new deployment
 func (c *Client) CreateDeployment(deployment *DeploymentCreate) (*DeploymentResponse, error) {
 	resp, err := c.makeRequest("POST", "/api/v1/deploy", deployment)
@@ -26,6 +30,72 @@ func (c *Client) CreateDeployment(deployment *DeploymentCreate) (*DeploymentResp
 	return &deploymentResp, nil
 }
 
+// UpdateDeployment replaces the spec of an existing deployment
+func (c *Client) UpdateDeployment(deploymentID string, deployment *DeploymentCreate) (*DeploymentResponse, error) {
+	endpoint := fmt.Sprintf("/api/v1/deployments/%s", deploymentID)
+
+	resp, err := c.makeRequest("PUT", endpoint, deployment)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleAPIError(resp)
+	}
+
+	var deploymentResp DeploymentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&deploymentResp); err != nil {
+		return nil, fmt.Errorf("failed to decode deployment response: %w", err)
+	}
+
+	return &deploymentResp, nil
+}
+
+// PatchDeployment changes only the fields set in the update, rolling the deployment in place
+func (c *Client) PatchDeployment(deploymentID string, update *DeploymentUpdate) (*DeploymentResponse, error) {
+	endpoint := fmt.Sprintf("/api/v1/deployments/%s", deploymentID)
+
+	resp, err := c.makeRequest("PATCH", endpoint, update)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleAPIError(resp)
+	}
+
+	var deploymentResp DeploymentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&deploymentResp); err != nil {
+		return nil, fmt.Errorf("failed to decode deployment response: %w", err)
+	}
+
+	return &deploymentResp, nil
+}
+
+// UpdateDeploymentEnv changes the environment variables of a deployment, rolling it in place
+func (c *Client) UpdateDeploymentEnv(deploymentID string, update *EnvUpdate) (*DeploymentResponse, error) {
+	endpoint := fmt.Sprintf("/api/v1/deployments/%s/env", deploymentID)
+
+	resp, err := c.makeRequest("PATCH", endpoint, update)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleAPIError(resp)
+	}
+
+	var deploymentResp DeploymentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&deploymentResp); err != nil {
+		return nil, fmt.Errorf("failed to decode deployment response: %w", err)
+	}
+
+	return &deploymentResp, nil
+}
+
 // ListDeployments lists all deployments
 func (c *Client) ListDeployments() (*DeploymentList, error) {
 	resp, err := c.makeRequest("GET", "/api/v1/deployments", nil)
@@ -46,6 +116,45 @@ func (c *Client) ListDeployments() (*DeploymentList, error) {
 	return &deploymentList, nil
 }
 
+// FindDeploymentByName returns the deployment with the given app name,
+// or ErrDeploymentNotFound when there is none
+func (c *Client) FindDeploymentByName(appName string) (*DeploymentResponse, error) {
+	deploymentList, err := c.ListDeployments()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range deploymentList.Deployments {
+		if deploymentList.Deployments[i].AppName == appName {
+			return &deploymentList.Deployments[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no deployment with app name %s", ErrDeploymentNotFound, appName)
+}
+
+// GetDeployment gets a deployment by ID
+func (c *Client) GetDeployment(deploymentID string) (*DeploymentResponse, error) {
+	endpoint := fmt.Sprintf("/api/v1/deployments/%s", deploymentID)
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleAPIError(resp)
+	}
+
+	var deploymentResp DeploymentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&deploymentResp); err != nil {
+		return nil, fmt.Errorf("failed to decode deployment response: %w", err)
+	}
+
+	return &deploymentResp, nil
+}
+
 // GetDeploymentStatus gets deployment status by ID
 func (c *Client) GetDeploymentStatus(deploymentID string) (*DeploymentStatus, error) {
 	endpoint := fmt.Sprintf("/api/v1/deployments/%s/status", deploymentID)
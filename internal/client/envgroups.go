@@ -0,0 +1,154 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// CreateEnvGroup creates a new env group
+func (c *Client) CreateEnvGroup(group *EnvGroupCreate) (*EnvGroup, error) {
+	resp, err := c.makeRequest("POST", "/api/v1/envgroups", group)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, handleAPIError(resp)
+	}
+
+	var envGroup EnvGroup
+	if err := json.NewDecoder(resp.Body).Decode(&envGroup); err != nil {
+		return nil, fmt.Errorf("failed to decode env group: %w", err)
+	}
+
+	return &envGroup, nil
+}
+
+// ListEnvGroups lists all env groups
+func (c *Client) ListEnvGroups() (*EnvGroupList, error) {
+	resp, err := c.makeRequest("GET", "/api/v1/envgroups", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleAPIError(resp)
+	}
+
+	var groupList EnvGroupList
+	if err := json.NewDecoder(resp.Body).Decode(&groupList); err != nil {
+		return nil, fmt.Errorf("failed to decode env group list: %w", err)
+	}
+
+	return &groupList, nil
+}
+
+// GetEnvGroup gets an env group by name
+func (c *Client) GetEnvGroup(name string) (*EnvGroup, error) {
+	endpoint := fmt.Sprintf("/api/v1/envgroups/%s", url.PathEscape(name))
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleAPIError(resp)
+	}
+
+	var envGroup EnvGroup
+	if err := json.NewDecoder(resp.Body).Decode(&envGroup); err != nil {
+		return nil, fmt.Errorf("failed to decode env group: %w", err)
+	}
+
+	return &envGroup, nil
+}
+
+// UpdateEnvGroup changes the variables of an env group; every deployment that
+// references the group is rolled to pick up the change
+func (c *Client) UpdateEnvGroup(name string, update *EnvUpdate) (*EnvGroup, error) {
+	endpoint := fmt.Sprintf("/api/v1/envgroups/%s", url.PathEscape(name))
+
+	resp, err := c.makeRequest("PATCH", endpoint, update)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleAPIError(resp)
+	}
+
+	var envGroup EnvGroup
+	if err := json.NewDecoder(resp.Body).Decode(&envGroup); err != nil {
+		return nil, fmt.Errorf("failed to decode env group: %w", err)
+	}
+
+	return &envGroup, nil
+}
+
+// DeleteEnvGroup deletes an env group by name
+func (c *Client) DeleteEnvGroup(name string) error {
+	endpoint := fmt.Sprintf("/api/v1/envgroups/%s", url.PathEscape(name))
+
+	resp, err := c.makeRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return handleAPIError(resp)
+	}
+
+	return nil
+}
+
+// AttachEnvGroup makes a deployment reference an env group, after the groups it already references
+func (c *Client) AttachEnvGroup(deploymentID, name string) (*DeploymentResponse, error) {
+	endpoint := fmt.Sprintf("/api/v1/deployments/%s/envgroups", deploymentID)
+
+	resp, err := c.makeRequest("POST", endpoint, EnvGroupAttach{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleAPIError(resp)
+	}
+
+	var deploymentResp DeploymentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&deploymentResp); err != nil {
+		return nil, fmt.Errorf("failed to decode deployment response: %w", err)
+	}
+
+	return &deploymentResp, nil
+}
+
+// DetachEnvGroup removes an env group from a deployment
+func (c *Client) DetachEnvGroup(deploymentID, name string) (*DeploymentResponse, error) {
+	endpoint := fmt.Sprintf("/api/v1/deployments/%s/envgroups/%s", deploymentID, url.PathEscape(name))
+
+	resp, err := c.makeRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleAPIError(resp)
+	}
+
+	var deploymentResp DeploymentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&deploymentResp); err != nil {
+		return nil, fmt.Errorf("failed to decode deployment response: %w", err)
+	}
+
+	return &deploymentResp, nil
+}
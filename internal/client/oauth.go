@@ -0,0 +1,153 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// CLIClientID identifies the CLI to the authorization server
+const CLIClientID = "coderun-cli"
+
+// OAuth grant types used by the CLI
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// Errors returned while polling for a device code token
+var (
+	ErrAuthorizationPending = errors.New("authorization pending")
+	ErrSlowDown             = errors.New("polling too fast, slow down")
+	ErrDeviceCodeExpired    = errors.New("device code expired, please start the login again")
+	ErrAccessDenied         = errors.New("authorization request was denied")
+)
+
+// NewPKCE generates a PKCE code verifier and its S256 code challenge
+func NewPKCE() (verifier, challenge string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(buf)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge = base64.RawURLEncoding.EncodeToString(sum[:])
+	return verifier, challenge, nil
+}
+
+// AuthorizationURL builds the URL the user opens in a browser to authorize the CLI
+func (c *Client) AuthorizationURL(redirectURI, state, codeChallenge string) string {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", CLIClientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("state", state)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	return c.BaseURL + "/api/v1/auth/authorize?" + params.Encode()
+}
+
+// ExchangeAuthorizationCode exchanges an authorization code for an access token
+func (c *Client) ExchangeAuthorizationCode(code, codeVerifier, redirectURI string) (*LoginResponse, error) {
+	return c.requestToken(TokenRequest{
+		GrantType:    GrantTypeAuthorizationCode,
+		ClientID:     CLIClientID,
+		Code:         code,
+		CodeVerifier: codeVerifier,
+		RedirectURI:  redirectURI,
+	})
+}
+
+// RequestDeviceCode starts the device authorization flow
+func (c *Client) RequestDeviceCode() (*DeviceCodeResponse, error) {
+	deviceReq := DeviceCodeRequest{ClientID: CLIClientID}
+
+	resp, err := c.makeRequest("POST", "/api/v1/auth/device/code", deviceReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleAPIError(resp)
+	}
+
+	var deviceResp DeviceCodeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&deviceResp); err != nil {
+		return nil, fmt.Errorf("failed to decode device code response: %w", err)
+	}
+
+	return &deviceResp, nil
+}
+
+// PollDeviceToken asks once whether the device code has been authorized.
+// It returns ErrAuthorizationPending or ErrSlowDown while the user has not finished.
+func (c *Client) PollDeviceToken(deviceCode string) (*LoginResponse, error) {
+	return c.requestToken(TokenRequest{
+		GrantType:  GrantTypeDeviceCode,
+		ClientID:   CLIClientID,
+		DeviceCode: deviceCode,
+	})
+}
+
+// requestToken calls the token endpoint and maps OAuth error codes to errors
+func (c *Client) requestToken(tokenReq TokenRequest) (*LoginResponse, error) {
+	resp, err := c.makeRequest("POST", "/api/v1/auth/token", tokenReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleOAuthError(resp)
+	}
+
+	var loginResp LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&loginResp); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	return &loginResp, nil
+}
+
+// handleOAuthError handles token endpoint errors as defined in RFC 6749 and RFC 8628
+func handleOAuthError(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("HTTP %d: failed to read error response", resp.StatusCode)
+	}
+
+	var oauthErr struct {
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+		Detail      string `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &oauthErr); err != nil {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	switch oauthErr.Error {
+	case "authorization_pending":
+		return ErrAuthorizationPending
+	case "slow_down":
+		return ErrSlowDown
+	case "expired_token":
+		return ErrDeviceCodeExpired
+	case "access_denied":
+		return ErrAccessDenied
+	case "":
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, oauthErr.Detail)
+	}
+
+	if oauthErr.Description != "" {
+		return fmt.Errorf("HTTP %d: %s: %s", resp.StatusCode, oauthErr.Error, oauthErr.Description)
+	}
+	return fmt.Errorf("HTTP %d: %s", resp.StatusCode, oauthErr.Error)
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// authServer is a stub authorization server for the PKCE and device code flows
+type authServer struct {
+	mu            sync.Mutex
+	challenge     string
+	redirectURI   string
+	devicePending int
+}
+
+func newAuthServer(t *testing.T) *httptest.Server {
+	a := &authServer{devicePending: 2}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/auth/authorize", a.authorize)
+	mux.HandleFunc("/api/v1/auth/token", a.token)
+	mux.HandleFunc("/api/v1/auth/device/code", a.deviceCode)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// authorize plays the user approving the login: it redirects back with a code
+func (a *authServer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != CLIClientID || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "bad authorization request", http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	a.challenge = query.Get("code_challenge")
+	a.redirectURI = query.Get("redirect_uri")
+	a.mu.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	params := url.Values{"code": {"auth-code"}, "state": {query.Get("state")}}
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (a *authServer) token(w http.ResponseWriter, r *http.Request) {
+	var req TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode:
+		sum := sha256.Sum256([]byte(req.CodeVerifier))
+		if req.Code != "auth-code" || req.RedirectURI != a.redirectURI ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != a.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(LoginResponse{AccessToken: "web-token", TokenType: "bearer"})
+	case GrantTypeDeviceCode:
+		if a.devicePending > 0 {
+			a.devicePending--
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "authorization_pending"})
+			return
+		}
+		json.NewEncoder(w).Encode(LoginResponse{AccessToken: "device-token", TokenType: "bearer"})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "unsupported_grant_type"})
+	}
+}
+
+func (a *authServer) deviceCode(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DeviceCodeResponse{
+		DeviceCode:      "device-code",
+		UserCode:        "ABCD-EFGH",
+		VerificationURI: "https://example.com/device",
+		ExpiresIn:       60,
+		Interval:        1,
+	})
+}
+
+func TestExchangeAuthorizationCode(t *testing.T) {
+	server := newAuthServer(t)
+	apiClient := NewClient(server.URL)
+
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirectURI := "http://127.0.0.1:1/callback"
+
+	// Follow the authorization redirect without calling the redirect URI
+	noFollow := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := noFollow.Get(apiClient.AuthorizationURL(redirectURI, "state-1", challenge))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || location.Query().Get("state") != "state-1" {
+		t.Fatalf("redirect = %q, want the state echoed back", resp.Header.Get("Location"))
+	}
+
+	// The token endpoint checks the PKCE verifier against the challenge
+	if _, err := apiClient.ExchangeAuthorizationCode("auth-code", "wrong-verifier", redirectURI); err == nil {
+		t.Fatal("exchange with the wrong verifier succeeded")
+	}
+	loginResp, err := apiClient.ExchangeAuthorizationCode(location.Query().Get("code"), verifier, redirectURI)
+	if err != nil {
+		t.Fatalf("ExchangeAuthorizationCode: %v", err)
+	}
+	if loginResp.AccessToken != "web-token" {
+		t.Fatalf("access token = %q, want web-token", loginResp.AccessToken)
+	}
+}
+
+func TestDeviceCodeLogin(t *testing.T) {
+	server := newAuthServer(t)
+	apiClient := NewClient(server.URL)
+
+	deviceResp, err := apiClient.RequestDeviceCode()
+	if err != nil {
+		t.Fatalf("RequestDeviceCode: %v", err)
+	}
+	if deviceResp.UserCode != "ABCD-EFGH" {
+		t.Fatalf("user code = %q", deviceResp.UserCode)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := apiClient.PollDeviceToken(deviceResp.DeviceCode); !errors.Is(err, ErrAuthorizationPending) {
+			t.Fatalf("poll %d: got %v, want ErrAuthorizationPending", i+1, err)
+		}
+	}
+	loginResp, err := apiClient.PollDeviceToken(deviceResp.DeviceCode)
+	if err != nil {
+		t.Fatalf("PollDeviceToken: %v", err)
+	}
+	if loginResp.AccessToken != "device-token" {
+		t.Fatalf("access token = %q, want device-token", loginResp.AccessToken)
+	}
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrRevisionsNotSupported is returned when the server does not keep revision history
+var ErrRevisionsNotSupported = errors.New("revision history is not supported by the server")
+
+// ListRevisions gets the revision history of a deployment, oldest first
+func (c *Client) ListRevisions(deploymentID string) (*RevisionList, error) {
+	endpoint := fmt.Sprintf("/api/v1/deployments/%s/revisions", deploymentID)
+
+	resp, err := c.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return nil, ErrRevisionsNotSupported
+	default:
+		return nil, handleAPIError(resp)
+	}
+
+	var revisionList RevisionList
+	if err := json.NewDecoder(resp.Body).Decode(&revisionList); err != nil {
+		return nil, fmt.Errorf("failed to decode revision list: %w", err)
+	}
+
+	return &revisionList, nil
+}
+
+// RollbackDeployment re-applies the spec of an earlier revision.
+// A zero revision rolls back to the previous one.
+func (c *Client) RollbackDeployment(deploymentID string, revision int) (*DeploymentResponse, error) {
+	endpoint := fmt.Sprintf("/api/v1/deployments/%s/rollback", deploymentID)
+
+	resp, err := c.makeRequest("POST", endpoint, RollbackRequest{Revision: revision})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleAPIError(resp)
+	}
+
+	var deploymentResp DeploymentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&deploymentResp); err != nil {
+		return nil, fmt.Errorf("failed to decode deployment response: %w", err)
+	}
+
+	return &deploymentResp, nil
+}
@@ -12,11 +12,51 @@ type LoginRequest struct {
 
 // LoginResponse represents a login response
 type LoginResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
 }
 
-// DeploymentCreate represents a deployment creation request
+// RefreshRequest represents a token refresh request
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RevokeRequest represents a token revocation request
+type RevokeRequest struct {
+	Token         string `json:"token"`
+	TokenTypeHint string `json:"token_type_hint,omitempty"`
+}
+
+// TokenRequest represents a request to the OAuth token endpoint
+type TokenRequest struct {
+	GrantType    string `json:"grant_type"`
+	ClientID     string `json:"client_id"`
+	Code         string `json:"code,omitempty"`
+	CodeVerifier string `json:"code_verifier,omitempty"`
+	RedirectURI  string `json:"redirect_uri,omitempty"`
+	DeviceCode   string `json:"device_code,omitempty"`
+}
+
+// DeviceCodeRequest represents a device authorization request
+type DeviceCodeRequest struct {
+	ClientID string `json:"client_id"`
+}
+
+// DeviceCodeResponse represents a device authorization response
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// DeploymentCreate represents a deployment creation request.
+// SecretVars are write-only: the API never returns their values, and a nil map keeps
+// the existing secrets when a deployment is updated.
 type DeploymentCreate struct {
 	AppName                   string            `json:"app_name"`
 	Image                     string            `json:"image"`
@@ -28,10 +68,64 @@ type DeploymentCreate struct {
 	HTTPPort                  *int              `json:"http_port,omitempty"`
 	TCPPort                   *int              `json:"tcp_port,omitempty"`
 	EnvironmentVars           map[string]string `json:"environment_vars,omitempty"`
+	SecretVars                map[string]string `json:"secret_vars,omitempty"`
 	PersistentVolumeSize      string            `json:"persistent_volume_size,omitempty"`
 	PersistentVolumeMountPath string            `json:"persistent_volume_mount_path,omitempty"`
 }
 
+// DeploymentUpdate represents a partial deployment update. Nil fields are left unchanged;
+// a non-nil EnvironmentVars replaces all environment variables.
+type DeploymentUpdate struct {
+	Image           *string           `json:"image,omitempty"`
+	Replicas        *int              `json:"replicas,omitempty"`
+	CPULimit        *string           `json:"cpu_limit,omitempty"`
+	MemoryLimit     *string           `json:"memory_limit,omitempty"`
+	CPURequest      *string           `json:"cpu_request,omitempty"`
+	MemoryRequest   *string           `json:"memory_request,omitempty"`
+	EnvironmentVars map[string]string `json:"environment_vars,omitempty"`
+}
+
+// IsEmpty reports whether the update changes nothing
+func (u *DeploymentUpdate) IsEmpty() bool {
+	return u.Image == nil && u.Replicas == nil && u.CPULimit == nil && u.MemoryLimit == nil &&
+		u.CPURequest == nil && u.MemoryRequest == nil && u.EnvironmentVars == nil
+}
+
+// ApplyTo sets the fields of the update on a deployment spec
+func (u *DeploymentUpdate) ApplyTo(spec *DeploymentCreate) {
+	if u.Image != nil {
+		spec.Image = *u.Image
+	}
+	if u.Replicas != nil {
+		spec.Replicas = *u.Replicas
+	}
+	if u.CPULimit != nil {
+		spec.CPULimit = *u.CPULimit
+	}
+	if u.MemoryLimit != nil {
+		spec.MemoryLimit = *u.MemoryLimit
+	}
+	if u.CPURequest != nil {
+		spec.CPURequest = *u.CPURequest
+	}
+	if u.MemoryRequest != nil {
+		spec.MemoryRequest = *u.MemoryRequest
+	}
+	if u.EnvironmentVars != nil {
+		spec.EnvironmentVars = u.EnvironmentVars
+	}
+}
+
+// EnvUpdate represents a change to the environment of a deployment.
+// Set adds or replaces plain variables, or replaces all of them when Replace is true;
+// SetSecrets adds or replaces secrets; Unset removes plain variables or secrets.
+type EnvUpdate struct {
+	Set        map[string]string `json:"set,omitempty"`
+	SetSecrets map[string]string `json:"set_secrets,omitempty"`
+	Unset      []string          `json:"unset,omitempty"`
+	Replace    bool              `json:"replace,omitempty"`
+}
+
 // DeploymentResponse represents a deployment response
 type DeploymentResponse struct {
 	ID                        string            `json:"id"`
@@ -47,6 +141,8 @@ type DeploymentResponse struct {
 	TCPPort                   *int              `json:"tcp_port"`
 	TCPNodePort               *int              `json:"tcp_node_port"`
 	EnvironmentVars           map[string]string `json:"environment_vars"`
+	SecretKeys                []string          `json:"secret_keys,omitempty"`
+	EnvGroups                 []string          `json:"env_groups,omitempty"`
 	PersistentVolumeSize      string            `json:"persistent_volume_size,omitempty"`
 	PersistentVolumeMountPath string            `json:"persistent_volume_mount_path,omitempty"`
 	Status                    string            `json:"status"`
@@ -56,12 +152,87 @@ type DeploymentResponse struct {
 	TCPConnection             *string           `json:"tcp_connection"`
 }
 
+// Spec returns the deployment request that describes the live deployment
+func (d *DeploymentResponse) Spec() *DeploymentCreate {
+	spec := &DeploymentCreate{
+		AppName:                   d.AppName,
+		Image:                     d.Image,
+		Replicas:                  d.Replicas,
+		CPULimit:                  d.CPULimit,
+		MemoryLimit:               d.MemoryLimit,
+		CPURequest:                d.CPURequest,
+		MemoryRequest:             d.MemoryRequest,
+		HTTPPort:                  d.HTTPPort,
+		TCPPort:                   d.TCPPort,
+		PersistentVolumeSize:      d.PersistentVolumeSize,
+		PersistentVolumeMountPath: d.PersistentVolumeMountPath,
+	}
+	if len(d.EnvironmentVars) > 0 {
+		spec.EnvironmentVars = make(map[string]string, len(d.EnvironmentVars))
+		for key, value := range d.EnvironmentVars {
+			spec.EnvironmentVars[key] = value
+		}
+	}
+	return spec
+}
+
 // DeploymentList represents a list of deployments
 type DeploymentList struct {
 	Deployments []DeploymentResponse `json:"deployments"`
 	Total       int                  `json:"total"`
 }
 
+// Revision represents a spec a deployment ran at some point
+type Revision struct {
+	Revision  int              `json:"revision"`
+	Spec      DeploymentCreate `json:"spec"`
+	EnvHash   string           `json:"env_hash,omitempty"`
+	Author    string           `json:"author,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// EnvGroup is a named set of environment variables shared by several deployments.
+// Deployments list the groups they reference in attach order; later groups override
+// earlier ones, and the deployment's own variables override all groups.
+type EnvGroup struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Vars        map[string]string `json:"vars"`
+	SecretKeys  []string          `json:"secret_keys,omitempty"`
+	Deployments []string          `json:"deployments,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   *time.Time        `json:"updated_at"`
+}
+
+// EnvGroupCreate represents an env group creation request
+type EnvGroupCreate struct {
+	Name       string            `json:"name"`
+	Vars       map[string]string `json:"vars,omitempty"`
+	SecretVars map[string]string `json:"secret_vars,omitempty"`
+}
+
+// EnvGroupList represents a list of env groups
+type EnvGroupList struct {
+	EnvGroups []EnvGroup `json:"env_groups"`
+	Total     int        `json:"total"`
+}
+
+// EnvGroupAttach represents a request to attach an env group to a deployment
+type EnvGroupAttach struct {
+	Name string `json:"name"`
+}
+
+// RevisionList represents the revision history of a deployment, oldest first
+type RevisionList struct {
+	Revisions []Revision `json:"revisions"`
+	Total     int        `json:"total"`
+}
+
+// RollbackRequest represents a rollback request; a zero revision means the previous one
+type RollbackRequest struct {
+	Revision int `json:"revision,omitempty"`
+}
+
 // DeploymentStatus represents deployment status details
 type DeploymentStatus struct {
 	AppName                   string              `json:"app_name"`
@@ -93,6 +264,37 @@ type UserInfo struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// APITokenCreate represents a personal access token creation request.
+// ExpiresInDays set to 0 asks for a token that never expires; nil leaves the server default.
+type APITokenCreate struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes,omitempty"`
+	ExpiresInDays *int     `json:"expires_in_days,omitempty"`
+}
+
+// APIToken represents a personal access token (without its secret value)
+type APIToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// APITokenCreateResponse represents a newly created token, including its secret value
+type APITokenCreateResponse struct {
+	APIToken
+	Token string `json:"token"`
+}
+
+// APITokenList represents a list of personal access tokens
+type APITokenList struct {
+	Tokens []APIToken `json:"tokens"`
+	Total  int        `json:"total"`
+}
+
 // PodLogs represents logs from a single pod
 type PodLogs struct {
 	Status       string `json:"status"`
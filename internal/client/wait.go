@@ -0,0 +1,226 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Default polling settings used by the wait helpers
+const (
+	DefaultPollInterval    = 2 * time.Second
+	DefaultMaxPollInterval = 10 * time.Second
+	DefaultPollMultiplier  = 1.5
+)
+
+// Build and deployment states reported by the API
+const (
+	BuildStatusCompleted   = "completed"
+	BuildStatusFailed      = "failed"
+	DeploymentStatusFailed = "failed"
+)
+
+// WaitTimeoutError is returned when the context ends before the awaited condition is met
+type WaitTimeoutError struct {
+	// What is being waited for, e.g. "build 1234"
+	What string
+	// State is the last observed state
+	State string
+	// Err is the context error
+	Err error
+}
+
+func (e *WaitTimeoutError) Error() string {
+	if e.State == "" {
+		return fmt.Sprintf("timed out waiting for %s", e.What)
+	}
+	return fmt.Sprintf("timed out waiting for %s (last state: %s)", e.What, e.State)
+}
+
+func (e *WaitTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// WaitFailedError is returned when the awaited resource reaches a terminal failure state
+type WaitFailedError struct {
+	What  string
+	State string
+}
+
+func (e *WaitFailedError) Error() string {
+	return fmt.Sprintf("%s %s", e.What, e.State)
+}
+
+// PollOptions configures how often the wait helpers poll. The interval starts at Interval
+// and grows by Multiplier after each poll, up to MaxInterval. Zero values use the defaults.
+type PollOptions struct {
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Multiplier  float64
+}
+
+// BuildWaitOptions configures WaitForBuild
+type BuildWaitOptions struct {
+	PollOptions
+	// Condition reports whether waiting is done; it defaults to BuildCompleted
+	Condition func(*BuildResponse) (bool, error)
+	// OnProgress is called with every polled build
+	OnProgress func(*BuildResponse)
+}
+
+// DeploymentWaitOptions configures WaitForDeploymentReady
+type DeploymentWaitOptions struct {
+	PollOptions
+	// Condition reports whether waiting is done; it defaults to DeploymentReady
+	Condition func(*DeploymentStatus) (bool, error)
+	// OnProgress is called with every polled status
+	OnProgress func(*DeploymentStatus)
+}
+
+// BuildCompleted is the default build condition: done when the build completed,
+// and a WaitFailedError when it failed
+func BuildCompleted(build *BuildResponse) (bool, error) {
+	switch build.Status {
+	case BuildStatusCompleted:
+		return true, nil
+	case BuildStatusFailed:
+		return false, &WaitFailedError{What: "build " + build.ID, State: build.Status}
+	}
+	return false, nil
+}
+
+// DeploymentReady is the default deployment condition: done when all desired replicas are ready,
+// and a WaitFailedError when the deployment failed
+func DeploymentReady(status *DeploymentStatus) (bool, error) {
+	if status.Status == DeploymentStatusFailed {
+		return false, &WaitFailedError{What: "deployment " + status.AppName, State: status.Status}
+	}
+	return status.ReplicasDesired > 0 && status.ReplicasReady >= status.ReplicasDesired, nil
+}
+
+// WaitForBuild polls a build until the condition is met, the condition fails or ctx ends.
+// It returns the last polled build.
+func (c *Client) WaitForBuild(ctx context.Context, buildID string, opts BuildWaitOptions) (*BuildResponse, error) {
+	condition := opts.Condition
+	if condition == nil {
+		condition = BuildCompleted
+	}
+
+	var build *BuildResponse
+	err := poll(ctx, opts.PollOptions, "build "+buildID, func() (bool, string, error) {
+		var err error
+		build, err = c.GetBuildStatus(buildID)
+		if err != nil {
+			return false, "", fmt.Errorf("error checking build status: %w", err)
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(build)
+		}
+		done, err := condition(build)
+		return done, build.Status, err
+	})
+
+	return build, err
+}
+
+// WaitForDeploymentReady polls a deployment's status until the condition is met,
+// the condition fails or ctx ends. It returns the last polled status.
+func (c *Client) WaitForDeploymentReady(ctx context.Context, deploymentID string, opts DeploymentWaitOptions) (*DeploymentStatus, error) {
+	condition := opts.Condition
+	if condition == nil {
+		condition = DeploymentReady
+	}
+
+	var status *DeploymentStatus
+	err := poll(ctx, opts.PollOptions, "deployment "+deploymentID, func() (bool, string, error) {
+		var err error
+		status, err = c.GetDeploymentStatus(deploymentID)
+		if err != nil {
+			return false, "", fmt.Errorf("error checking deployment status: %w", err)
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(status)
+		}
+		done, err := condition(status)
+		state := fmt.Sprintf("%s, %d/%d replicas ready", status.Status, status.ReplicasReady, status.ReplicasDesired)
+		return done, state, err
+	})
+
+	return status, err
+}
+
+// pollSchedule yields the sleep between polls: Interval at first, growing by Multiplier
+// after each poll up to MaxInterval
+type pollSchedule struct {
+	interval    time.Duration
+	maxInterval time.Duration
+	multiplier  float64
+}
+
+// newPollSchedule applies the defaults to zero PollOptions values
+func newPollSchedule(opts PollOptions) *pollSchedule {
+	s := &pollSchedule{
+		interval:    opts.Interval,
+		maxInterval: opts.MaxInterval,
+		multiplier:  opts.Multiplier,
+	}
+	if s.interval <= 0 {
+		s.interval = DefaultPollInterval
+	}
+	if s.maxInterval <= 0 {
+		s.maxInterval = DefaultMaxPollInterval
+	}
+	if s.maxInterval < s.interval {
+		s.maxInterval = s.interval
+	}
+	if s.multiplier < 1 {
+		s.multiplier = DefaultPollMultiplier
+	}
+	return s
+}
+
+// next returns the interval to sleep now and grows the following one
+func (s *pollSchedule) next() time.Duration {
+	interval := s.interval
+	s.interval = time.Duration(float64(s.interval) * s.multiplier)
+	if s.interval > s.maxInterval {
+		s.interval = s.maxInterval
+	}
+	return interval
+}
+
+// poll calls check until it reports done or fails, sleeping with backoff in between.
+// When ctx ends first it returns a WaitTimeoutError with the last state reported by check.
+func poll(ctx context.Context, opts PollOptions, what string, check func() (bool, string, error)) error {
+	schedule := newPollSchedule(opts)
+
+	var state string
+	timeout := func() error {
+		return &WaitTimeoutError{What: what, State: state, Err: ctx.Err()}
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return timeout()
+		}
+
+		done, lastState, err := check()
+		if lastState != "" {
+			state = lastState
+		}
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		timer := time.NewTimer(schedule.next())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return timeout()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fastPoll keeps the tests quick
+var fastPoll = PollOptions{Interval: time.Millisecond, MaxInterval: 2 * time.Millisecond}
+
+// sequenceServer answers each GET on path with the next response, repeating the last one
+func sequenceServer(t *testing.T, path string, responses ...interface{}) (*httptest.Server, func() int) {
+	t.Helper()
+
+	var mu sync.Mutex
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		response := responses[min(calls, len(responses)-1)]
+		calls++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+}
+
+func TestWaitForBuild(t *testing.T) {
+	server, calls := sequenceServer(t, "/api/v1/builds/b1",
+		BuildResponse{ID: "b1", Status: "pending"},
+		BuildResponse{ID: "b1", Status: "building"},
+		BuildResponse{ID: "b1", Status: BuildStatusCompleted, ImageURI: "registry/app:1"},
+	)
+
+	var seen []string
+	build, err := NewClient(server.URL).WaitForBuild(context.Background(), "b1", BuildWaitOptions{
+		PollOptions: fastPoll,
+		OnProgress:  func(b *BuildResponse) { seen = append(seen, b.Status) },
+	})
+	if err != nil {
+		t.Fatalf("WaitForBuild: %v", err)
+	}
+	if build.ImageURI != "registry/app:1" {
+		t.Fatalf("image = %q", build.ImageURI)
+	}
+	if calls() != 3 || len(seen) != 3 {
+		t.Fatalf("polled %d times with %d progress calls, want 3 and 3", calls(), len(seen))
+	}
+	if seen[0] != "pending" || seen[1] != "building" || seen[2] != BuildStatusCompleted {
+		t.Fatalf("progress states = %v", seen)
+	}
+}
+
+func TestWaitForBuildFailed(t *testing.T) {
+	server, _ := sequenceServer(t, "/api/v1/builds/b1",
+		BuildResponse{ID: "b1", Status: "building"},
+		BuildResponse{ID: "b1", Status: BuildStatusFailed},
+	)
+
+	_, err := NewClient(server.URL).WaitForBuild(context.Background(), "b1", BuildWaitOptions{PollOptions: fastPoll})
+	var failed *WaitFailedError
+	if !errors.As(err, &failed) {
+		t.Fatalf("got %v, want a *WaitFailedError", err)
+	}
+	if failed.State != BuildStatusFailed {
+		t.Fatalf("state = %q, want failed", failed.State)
+	}
+}
+
+func TestWaitForBuildTimeout(t *testing.T) {
+	server, _ := sequenceServer(t, "/api/v1/builds/b1", BuildResponse{ID: "b1", Status: "building"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := NewClient(server.URL).WaitForBuild(ctx, "b1", BuildWaitOptions{PollOptions: fastPoll})
+
+	var timeout *WaitTimeoutError
+	if !errors.As(err, &timeout) {
+		t.Fatalf("got %v, want a *WaitTimeoutError", err)
+	}
+	if timeout.State != "building" {
+		t.Fatalf("last state = %q, want building", timeout.State)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("errors.Is(%v, context.DeadlineExceeded) = false", err)
+	}
+}
+
+func TestWaitForDeploymentReady(t *testing.T) {
+	server, calls := sequenceServer(t, "/api/v1/deployments/d1/status",
+		DeploymentStatus{AppName: "web", Status: "deploying", ReplicasDesired: 2},
+		DeploymentStatus{AppName: "web", Status: "deploying", ReplicasReady: 1, ReplicasDesired: 2},
+		DeploymentStatus{AppName: "web", Status: "running", ReplicasReady: 2, ReplicasDesired: 2},
+	)
+
+	progress := 0
+	status, err := NewClient(server.URL).WaitForDeploymentReady(context.Background(), "d1", DeploymentWaitOptions{
+		PollOptions: fastPoll,
+		OnProgress:  func(*DeploymentStatus) { progress++ },
+	})
+	if err != nil {
+		t.Fatalf("WaitForDeploymentReady: %v", err)
+	}
+	if status.ReplicasReady != 2 {
+		t.Fatalf("ready replicas = %d, want 2", status.ReplicasReady)
+	}
+	if progress != calls() || progress != 3 {
+		t.Fatalf("%d progress calls for %d polls, want 3", progress, calls())
+	}
+}
+
+func TestWaitForDeploymentFailed(t *testing.T) {
+	server, _ := sequenceServer(t, "/api/v1/deployments/d1/status",
+		DeploymentStatus{AppName: "web", Status: DeploymentStatusFailed, ReplicasDesired: 1},
+	)
+
+	_, err := NewClient(server.URL).WaitForDeploymentReady(context.Background(), "d1", DeploymentWaitOptions{PollOptions: fastPoll})
+	var failed *WaitFailedError
+	if !errors.As(err, &failed) {
+		t.Fatalf("got %v, want a *WaitFailedError", err)
+	}
+}
+
+func TestWaitCustomCondition(t *testing.T) {
+	server, calls := sequenceServer(t, "/api/v1/deployments/d1/status",
+		DeploymentStatus{AppName: "web", Status: "deploying", ReplicasDesired: 3},
+		DeploymentStatus{AppName: "web", Status: "deploying", ReplicasReady: 1, ReplicasDesired: 3},
+		DeploymentStatus{AppName: "web", Status: "deploying", ReplicasReady: 2, ReplicasDesired: 3},
+	)
+
+	// Done as soon as one replica is ready, before the default condition would be
+	status, err := NewClient(server.URL).WaitForDeploymentReady(context.Background(), "d1", DeploymentWaitOptions{
+		PollOptions: fastPoll,
+		Condition:   func(s *DeploymentStatus) (bool, error) { return s.ReplicasReady >= 1, nil },
+	})
+	if err != nil {
+		t.Fatalf("WaitForDeploymentReady: %v", err)
+	}
+	if status.ReplicasReady != 1 || calls() != 2 {
+		t.Fatalf("stopped at %d ready replicas after %d polls, want 1 after 2", status.ReplicasReady, calls())
+	}
+
+	// A condition error ends the wait as is
+	conditionErr := errors.New("giving up")
+	_, err = NewClient(server.URL).WaitForDeploymentReady(context.Background(), "d1", DeploymentWaitOptions{
+		PollOptions: fastPoll,
+		Condition:   func(*DeploymentStatus) (bool, error) { return false, conditionErr },
+	})
+	if !errors.Is(err, conditionErr) {
+		t.Fatalf("got %v, want the condition error", err)
+	}
+}
+
+func TestPollSchedule(t *testing.T) {
+	tests := []struct {
+		name string
+		opts PollOptions
+		want []time.Duration
+	}{
+		{
+			name: "grows up to the maximum",
+			opts: PollOptions{Interval: time.Second, MaxInterval: 5 * time.Second, Multiplier: 2},
+			want: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name: "defaults",
+			opts: PollOptions{},
+			want: []time.Duration{2 * time.Second, 3 * time.Second, 4500 * time.Millisecond, 6750 * time.Millisecond, 10 * time.Second, 10 * time.Second},
+		},
+		{
+			name: "maximum below the interval",
+			opts: PollOptions{Interval: 3 * time.Second, MaxInterval: time.Second, Multiplier: 2},
+			want: []time.Duration{3 * time.Second, 3 * time.Second},
+		},
+		{
+			name: "multiplier of one keeps the interval",
+			opts: PollOptions{Interval: time.Second, Multiplier: 1},
+			want: []time.Duration{time.Second, time.Second, time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := newPollSchedule(tt.opts)
+			for i, want := range tt.want {
+				if got := schedule.next(); got != want {
+					t.Fatalf("interval %d = %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
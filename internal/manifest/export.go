@@ -0,0 +1,87 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// Placeholder returns the ${KEY} reference used in place of a secret value.
+// apply fills it from --var or the process environment.
+func Placeholder(key string) string {
+	return "${" + key + "}"
+}
+
+// FromDeployment converts a live deployment into a manifest app.
+// Unless includeSecrets is set, values of plain variables that look like secrets are replaced by placeholders.
+// Secrets are always exported as placeholders, since their values are never returned by the API.
+func FromDeployment(deployment *client.DeploymentResponse, includeSecrets bool) *App {
+	replicas := deployment.Replicas
+	app := &App{
+		Name:          deployment.AppName,
+		Image:         escapeVariables(deployment.Image),
+		Replicas:      &replicas,
+		CPULimit:      deployment.CPULimit,
+		MemoryLimit:   deployment.MemoryLimit,
+		CPURequest:    deployment.CPURequest,
+		MemoryRequest: deployment.MemoryRequest,
+		HTTPPort:      deployment.HTTPPort,
+		TCPPort:       deployment.TCPPort,
+	}
+
+	if deployment.PersistentVolumeSize != "" || deployment.PersistentVolumeMountPath != "" {
+		app.Storage = &Storage{
+			Size: deployment.PersistentVolumeSize,
+			Path: deployment.PersistentVolumeMountPath,
+		}
+	}
+
+	if len(deployment.EnvironmentVars) > 0 {
+		app.Env = make(map[string]string, len(deployment.EnvironmentVars))
+		for key, value := range deployment.EnvironmentVars {
+			if !includeSecrets && utils.LooksLikeSecret(key) {
+				app.Env[key] = Placeholder(key)
+			} else {
+				app.Env[key] = escapeVariables(value)
+			}
+		}
+	}
+
+	if len(deployment.EnvGroups) > 0 {
+		app.EnvGroups = append([]string(nil), deployment.EnvGroups...)
+	}
+
+	if len(deployment.SecretKeys) > 0 {
+		// Secret values are never returned by the API
+		app.Secrets = make(map[string]string, len(deployment.SecretKeys))
+		for _, key := range deployment.SecretKeys {
+			app.Secrets[key] = Placeholder(key)
+		}
+	}
+
+	return app
+}
+
+// Marshal encodes a manifest as YAML
+func Marshal(m *Manifest) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(m); err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// escapeVariables escapes $ so that exported values survive interpolation unchanged
+func escapeVariables(value string) string {
+	return strings.ReplaceAll(value, "$", "$$")
+}
@@ -0,0 +1,94 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// checkDependencies verifies that depends_on names exist and that there are no cycles
+func (m *Manifest) checkDependencies(d *document) error {
+	index := make(map[string]int, len(m.Apps))
+	for i, app := range m.Apps {
+		index[app.Name] = i
+	}
+
+	for i, app := range m.Apps {
+		for j, dep := range app.DependsOn {
+			path := []string{"apps", strconv.Itoa(i), "depends_on", strconv.Itoa(j)}
+			if _, ok := index[dep]; !ok {
+				return d.errorAt(fmt.Sprintf("app %q depends on unknown app %q", app.Name, dep), path...)
+			}
+			if dep == app.Name {
+				return d.errorAt(fmt.Sprintf("app %q depends on itself", app.Name), path...)
+			}
+		}
+	}
+
+	if cycle := findCycle(m.Apps); cycle != nil {
+		path := []string{"apps", strconv.Itoa(index[cycle[0]]), "depends_on"}
+		return d.errorAt("dependency cycle: "+strings.Join(cycle, " -> "), path...)
+	}
+
+	return nil
+}
+
+// findCycle returns the app names forming a dependency cycle, or nil when the graph is acyclic
+func findCycle(apps []*App) []string {
+	deps := make(map[string][]string, len(apps))
+	for _, app := range apps {
+		deps[app.Name] = app.DependsOn
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(apps))
+	var stack []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, dep := range deps[name] {
+			switch state[dep] {
+			case visiting:
+				// The cycle runs from dep's position on the stack back to dep
+				for i, n := range stack {
+					if n == dep {
+						return append(append([]string{}, stack[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+		return nil
+	}
+
+	for _, app := range apps {
+		if state[app.Name] == unvisited {
+			if cycle := visit(app.Name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Dependents returns, for each app name, the names of the apps that depend on it
+func (m *Manifest) Dependents() map[string][]string {
+	dependents := make(map[string][]string)
+	for _, app := range m.AllApps() {
+		for _, dep := range app.DependsOn {
+			dependents[dep] = append(dependents[dep], app.Name)
+		}
+	}
+	return dependents
+}
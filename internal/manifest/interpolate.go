@@ -0,0 +1,105 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// variablePattern matches $$, ${VAR} and ${VAR:-default}
+var variablePattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolate replaces variable references in every scalar value of the document.
+// ${VAR:-default} uses the default when VAR is unset or empty, and $$ is a literal $.
+func (d *document) interpolate(lookup func(string) (string, bool)) error {
+	var errs []error
+	d.interpolateNode(d.root, lookup, &errs)
+	retype(d.root, reflect.TypeOf(Manifest{}))
+	return errors.Join(errs...)
+}
+
+func (d *document) interpolateNode(node *yaml.Node, lookup func(string) (string, bool), errs *[]error) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		// Only values are interpolated, never keys
+		for i := 1; i < len(node.Content); i += 2 {
+			d.interpolateNode(node.Content[i], lookup, errs)
+		}
+	case yaml.SequenceNode, yaml.DocumentNode:
+		for _, child := range node.Content {
+			d.interpolateNode(child, lookup, errs)
+		}
+	case yaml.ScalarNode:
+		d.interpolateScalar(node, lookup, errs)
+	}
+}
+
+func (d *document) interpolateScalar(node *yaml.Node, lookup func(string) (string, bool), errs *[]error) {
+	if !variablePattern.MatchString(node.Value) {
+		return
+	}
+
+	value := variablePattern.ReplaceAllStringFunc(node.Value, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+
+		groups := variablePattern.FindStringSubmatch(match)
+		name, hasDefault, defaultValue := groups[1], groups[2] != "", groups[3]
+
+		if value, ok := lookup(name); ok && (value != "" || !hasDefault) {
+			return value
+		}
+		if hasDefault {
+			return defaultValue
+		}
+
+		*errs = append(*errs, &Error{
+			File:   d.fileOf(node),
+			Line:   node.Line,
+			Column: node.Column,
+			Msg:    fmt.Sprintf("variable %s is not set (use --var %s=... or ${%s:-default})", name, name, name),
+		})
+		return match
+	})
+
+	node.Value = value
+	// Interpolated values are strings, so "~", "true" or "0123" are not turned into null, a bool
+	// or a number; retype lets plain scalars of typed fields, such as replicas, resolve again
+	node.Tag = "!!str"
+}
+
+// retype clears the !!str tag of plain scalars decoded into non-string fields of t, so that
+// "replicas: ${REPLICAS:-2}" becomes an int while env values stay strings
+func retype(node *yaml.Node, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Style == 0 && node.Tag == "!!str" && t.Kind() != reflect.String && t.Kind() != reflect.Interface {
+			node.Tag = ""
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			switch t.Kind() {
+			case reflect.Struct:
+				if field, ok := fieldByYAMLName(t, node.Content[i].Value); ok {
+					retype(node.Content[i+1], field.Type)
+				}
+			case reflect.Map:
+				retype(node.Content[i+1], t.Elem())
+			}
+		}
+	case yaml.SequenceNode:
+		if t.Kind() == reflect.Slice {
+			for _, item := range node.Content {
+				retype(item, t.Elem())
+			}
+		}
+	}
+}
@@ -0,0 +1,593 @@
+// Package manifest loads and validates coderun.yaml application manifests.
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+// CurrentVersion is the manifest format version understood by this CLI
+const CurrentVersion = 1
+
+// DefaultFile is the manifest file used when none is given
+const DefaultFile = "coderun.yaml"
+
+// Manifest represents a coderun.yaml file. It declares either a single app or a list of apps.
+type Manifest struct {
+	Version int    `yaml:"version"`
+	App     *App   `yaml:"app,omitempty"`
+	Apps    []*App `yaml:"apps,omitempty"`
+
+	// Dir is the directory of the manifest file, used to resolve relative paths
+	Dir string `yaml:"-"`
+}
+
+// App describes one application. Its fields map one-to-one onto client.DeploymentCreate.
+type App struct {
+	Name          string            `yaml:"name"`
+	Image         string            `yaml:"image,omitempty"`
+	Build         *Build            `yaml:"build,omitempty"`
+	Replicas      *int              `yaml:"replicas,omitempty"`
+	CPULimit      string            `yaml:"cpu_limit,omitempty"`
+	MemoryLimit   string            `yaml:"memory_limit,omitempty"`
+	CPURequest    string            `yaml:"cpu_request,omitempty"`
+	MemoryRequest string            `yaml:"memory_request,omitempty"`
+	HTTPPort      *int              `yaml:"http_port,omitempty"`
+	TCPPort       *int              `yaml:"tcp_port,omitempty"`
+	Env           map[string]string `yaml:"env,omitempty"`
+	EnvFile       string            `yaml:"env_file,omitempty"`
+	Secrets       map[string]string `yaml:"secrets,omitempty"`
+	EnvGroups     []string          `yaml:"env_groups,omitempty"`
+	EnvSchema     utils.EnvSchema   `yaml:"env_schema,omitempty"`
+	Storage       *Storage          `yaml:"storage,omitempty"`
+	DependsOn     []string          `yaml:"depends_on,omitempty"`
+}
+
+// Build describes how to build the app image from source
+type Build struct {
+	Context    string `yaml:"context"`
+	Dockerfile string `yaml:"dockerfile,omitempty"`
+}
+
+// Storage describes a persistent volume
+type Storage struct {
+	Size string `yaml:"size"`
+	Path string `yaml:"path"`
+}
+
+// Error is a manifest error at a specific position
+type Error struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	if e.Line > 0 && e.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Msg)
+}
+
+// Options control how a manifest is loaded
+type Options struct {
+	// Overlays are applied on top of the base manifest, in order
+	Overlays []string
+	// Vars are used for ${VAR} interpolation before the process environment
+	Vars map[string]string
+	// LookupEnv reads the process environment; os.LookupEnv is used when nil
+	LookupEnv func(string) (string, bool)
+}
+
+// OverlayPath returns the overlay file for an environment, e.g. coderun.staging.yaml for coderun.yaml
+func OverlayPath(basePath, env string) string {
+	ext := filepath.Ext(basePath)
+	return strings.TrimSuffix(basePath, ext) + "." + env + ext
+}
+
+// Load reads a manifest file, applies overlays and interpolation, then strictly decodes and validates it
+func Load(path string, opts Options) (*Manifest, error) {
+	doc, err := render(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := doc.decode()
+	if err != nil {
+		return nil, err
+	}
+	m.Dir = filepath.Dir(path)
+
+	return m, nil
+}
+
+// Render returns the manifest after overlays and interpolation, as YAML.
+// Secret values are masked, since they may have been interpolated from the environment.
+func Render(path string, opts Options) ([]byte, error) {
+	doc, err := render(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	doc.maskSecrets()
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc.root); err != nil {
+		return nil, fmt.Errorf("failed to render manifest: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to render manifest: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Parse strictly decodes and validates manifest data without overlays or interpolation.
+// The filename is used in error messages.
+func Parse(data []byte, filename string) (*Manifest, error) {
+	doc, err := parseDocument(data, filename)
+	if err != nil {
+		return nil, err
+	}
+	return doc.decode()
+}
+
+// document is a parsed manifest tree that remembers which file each node came from
+type document struct {
+	root  *yaml.Node
+	file  string
+	files map[*yaml.Node]string
+}
+
+// render loads the base manifest, merges overlays and interpolates variables
+func render(path string, opts Options) (*document, error) {
+	doc, err := readDocument(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, overlayPath := range opts.Overlays {
+		overlay, err := readDocument(overlayPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := doc.merge(overlay); err != nil {
+			return nil, err
+		}
+	}
+
+	lookupEnv := opts.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	lookup := func(name string) (string, bool) {
+		if value, ok := opts.Vars[name]; ok {
+			return value, true
+		}
+		return lookupEnv(name)
+	}
+	if err := doc.interpolate(lookup); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// maskSecrets replaces the value of every secret of every app with utils.MaskedValue
+func (d *document) maskSecrets() {
+	apps := []*yaml.Node{findNode(d.root, "app")}
+	if list := findNode(d.root, "apps"); list != nil && list.Kind == yaml.SequenceNode {
+		apps = append(apps, list.Content...)
+	}
+
+	for _, app := range apps {
+		secrets := findNode(app, "secrets")
+		if secrets == nil || secrets.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 1; i < len(secrets.Content); i += 2 {
+			value := secrets.Content[i]
+			*value = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: utils.MaskedValue, Line: value.Line, Column: value.Column}
+		}
+	}
+}
+
+// readDocument reads and parses a manifest file
+func readDocument(path string) (*document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return parseDocument(data, path)
+}
+
+// parseDocument parses YAML data into a document
+func parseDocument(data []byte, filename string) (*document, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, &Error{File: filename, Msg: strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	if len(node.Content) == 0 {
+		return nil, &Error{File: filename, Msg: "manifest is empty"}
+	}
+
+	doc := &document{
+		root:  node.Content[0],
+		file:  filename,
+		files: make(map[*yaml.Node]string),
+	}
+	doc.track(doc.root, filename)
+
+	return doc, nil
+}
+
+// track records the source file of a node and all of its children
+func (d *document) track(node *yaml.Node, filename string) {
+	d.files[node] = filename
+	for _, child := range node.Content {
+		d.track(child, filename)
+	}
+}
+
+// fileOf returns the file a node was read from
+func (d *document) fileOf(node *yaml.Node) string {
+	if filename, ok := d.files[node]; ok {
+		return filename
+	}
+	return d.file
+}
+
+// errorAt builds an Error positioned at the node for path, falling back to the nearest parent node
+func (d *document) errorAt(msg string, path ...string) error {
+	node := d.root
+	for i := len(path); i >= 0; i-- {
+		if found := findNode(d.root, path[:i]...); found != nil {
+			node = found
+			break
+		}
+	}
+	return &Error{File: d.fileOf(node), Line: node.Line, Column: node.Column, Msg: msg}
+}
+
+// decode checks the document for unknown keys, decodes and validates it
+func (d *document) decode() (*Manifest, error) {
+	if errs := d.checkKnownFields(d.root, reflect.TypeOf(Manifest{})); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var m Manifest
+	if err := d.root.Decode(&m); err != nil {
+		return nil, d.decodeError(err)
+	}
+
+	if err := m.validate(d); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// decodeError converts yaml type errors ("line N: message") into positioned errors
+func (d *document) decodeError(err error) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return &Error{File: d.file, Msg: strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+
+	errs := make([]error, 0, len(typeErr.Errors))
+	for _, msg := range typeErr.Errors {
+		var line int
+		if _, scanErr := fmt.Sscanf(msg, "line %d:", &line); scanErr == nil {
+			msg = strings.TrimSpace(msg[strings.Index(msg, ":")+1:])
+		}
+		errs = append(errs, &Error{File: d.fileAtLine(d.root, line, msg), Line: line, Msg: msg})
+	}
+	return errors.Join(errs...)
+}
+
+// fileAtLine guesses which file a type error came from by finding the scalar
+// on that line whose value appears in the message
+func (d *document) fileAtLine(node *yaml.Node, line int, msg string) string {
+	if node.Kind == yaml.ScalarNode && node.Line == line && strings.Contains(msg, "`"+node.Value+"`") {
+		return d.fileOf(node)
+	}
+	for _, child := range node.Content {
+		if filename := d.fileAtLine(child, line, msg); filename != d.file {
+			return filename
+		}
+	}
+	return d.file
+}
+
+// checkKnownFields reports every mapping key that has no matching field in t
+func (d *document) checkKnownFields(node *yaml.Node, t reflect.Type) []error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var errs []error
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil // type mismatches are reported by Decode
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fieldByYAMLName(t, key.Value)
+			if !ok {
+				errs = append(errs, &Error{
+					File:   d.fileOf(key),
+					Line:   key.Line,
+					Column: key.Column,
+					Msg:    fmt.Sprintf("unknown key %q", key.Value),
+				})
+				continue
+			}
+			errs = append(errs, d.checkKnownFields(value, field.Type)...)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for _, item := range node.Content {
+			errs = append(errs, d.checkKnownFields(item, t.Elem())...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			errs = append(errs, d.checkKnownFields(node.Content[i], t.Elem())...)
+		}
+	}
+
+	return errs
+}
+
+// fieldByYAMLName finds the struct field decoded from the given key
+func fieldByYAMLName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		if tag == "" {
+			tag = strings.ToLower(field.Name)
+		}
+		if tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// findNode returns the value node at the given path of mapping keys and sequence indexes, or nil
+func findNode(node *yaml.Node, path ...string) *yaml.Node {
+	for _, key := range path {
+		if node == nil {
+			return nil
+		}
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == key {
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(key); err == nil && index >= 0 && index < len(node.Content) {
+				next = node.Content[index]
+			}
+		}
+		node = next
+	}
+	return node
+}
+
+// validate checks the manifest contents
+func (m *Manifest) validate(d *document) error {
+	if m.Version != CurrentVersion {
+		return d.errorAt(fmt.Sprintf("unsupported manifest version %d (expected %d)", m.Version, CurrentVersion), "version")
+	}
+	if m.App == nil && len(m.Apps) == 0 {
+		return d.errorAt("missing required key \"app\" or \"apps\"")
+	}
+	if m.App != nil && len(m.Apps) > 0 {
+		return d.errorAt("\"app\" and \"apps\" cannot be used together", "apps")
+	}
+
+	if m.App != nil {
+		if len(m.App.DependsOn) > 0 {
+			return d.errorAt("depends_on requires a multi-app manifest (\"apps\")", "app", "depends_on")
+		}
+		return m.App.validate(d, "app")
+	}
+
+	var errs []error
+	seen := make(map[string]bool)
+	for i, app := range m.Apps {
+		path := []string{"apps", strconv.Itoa(i)}
+		if app == nil {
+			errs = append(errs, d.errorAt("app entry is empty", path...))
+			continue
+		}
+		if err := app.validate(d, path...); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if seen[app.Name] {
+			errs = append(errs, d.errorAt(fmt.Sprintf("duplicate app name %q", app.Name), append(path, "name")...))
+		}
+		seen[app.Name] = true
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return m.checkDependencies(d)
+}
+
+// AllApps returns the apps declared in the manifest, whether single or multiple
+func (m *Manifest) AllApps() []*App {
+	if m.App != nil {
+		return []*App{m.App}
+	}
+	return m.Apps
+}
+
+// validate checks a single app; path is the key path of the app node
+func (a *App) validate(d *document, path ...string) error {
+	at := func(msg string, key ...string) error {
+		return d.errorAt(msg, append(append([]string{}, path...), key...)...)
+	}
+
+	if err := utils.ValidateAppName(a.Name); err != nil {
+		return at(err.Error(), "name")
+	}
+
+	// Exactly one image source
+	if a.Image == "" && a.Build == nil {
+		return at("either \"image\" or \"build\" is required")
+	}
+	if a.Image != "" && a.Build != nil {
+		return at("\"image\" and \"build\" cannot be used together", "build")
+	}
+	if a.Build != nil && a.Build.Context == "" {
+		return at("build context is required", "build")
+	}
+
+	for key := range a.Secrets {
+		if _, ok := a.Env[key]; ok {
+			return at(fmt.Sprintf("%s is set in both env and secrets", key), "secrets", key)
+		}
+	}
+
+	seenGroups := make(map[string]bool, len(a.EnvGroups))
+	for i, group := range a.EnvGroups {
+		if err := utils.ValidateAppName(group); err != nil {
+			return at(fmt.Sprintf("invalid env group name %q: %v", group, err), "env_groups", strconv.Itoa(i))
+		}
+		if seenGroups[group] {
+			return at(fmt.Sprintf("duplicate env group %q", group), "env_groups", strconv.Itoa(i))
+		}
+		seenGroups[group] = true
+	}
+
+	for key, rule := range a.EnvSchema {
+		if err := rule.Check(); err != nil {
+			return at(fmt.Sprintf("env_schema %s: %v", key, err), "env_schema", key)
+		}
+	}
+
+	if a.Storage != nil && a.Replicas != nil && *a.Replicas > 1 {
+		return at("replicas must be 1 when persistent storage is configured", "replicas")
+	}
+
+	spec := a.spec()
+	if err := utils.ValidateDeploymentSpec(&spec); err != nil {
+		return at(err.Error())
+	}
+
+	return nil
+}
+
+// spec converts the app to a deployment request without reading env files
+func (a *App) spec() client.DeploymentCreate {
+	spec := client.DeploymentCreate{
+		AppName:       a.Name,
+		Image:         a.Image,
+		Replicas:      1,
+		CPULimit:      a.CPULimit,
+		MemoryLimit:   a.MemoryLimit,
+		CPURequest:    a.CPURequest,
+		MemoryRequest: a.MemoryRequest,
+		HTTPPort:      a.HTTPPort,
+		TCPPort:       a.TCPPort,
+	}
+	if a.Replicas != nil {
+		spec.Replicas = *a.Replicas
+	}
+	if a.Storage != nil {
+		spec.PersistentVolumeSize = a.Storage.Size
+		spec.PersistentVolumeMountPath = a.Storage.Path
+	}
+	return spec
+}
+
+// DeploymentCreate converts the app to a deployment request.
+// Variables from env_file (relative to baseDir) are loaded first and overridden by env;
+// secrets are sent separately and cannot repeat a plain variable.
+func (a *App) DeploymentCreate(baseDir string) (*client.DeploymentCreate, error) {
+	spec := a.spec()
+
+	envVars := make(map[string]string)
+	if a.EnvFile != "" {
+		envPath := a.EnvFile
+		if !filepath.IsAbs(envPath) {
+			envPath = filepath.Join(baseDir, envPath)
+		}
+		fileVars, err := utils.ParseEnvFile(envPath)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fileVars {
+			envVars[key] = value
+		}
+	}
+	for key, value := range a.Env {
+		envVars[key] = value
+	}
+	if len(envVars) > 0 {
+		spec.EnvironmentVars = envVars
+	}
+
+	if len(a.Secrets) > 0 {
+		spec.SecretVars = make(map[string]string, len(a.Secrets))
+		for key, value := range a.Secrets {
+			if _, ok := envVars[key]; ok {
+				return nil, fmt.Errorf("%s is set both as a plain variable and in secrets", key)
+			}
+			spec.SecretVars[key] = value
+		}
+	}
+
+	return &spec, nil
+}
+
+// BuildContext returns the build context directory resolved against baseDir
+func (a *App) BuildContext(baseDir string) string {
+	if a.Build == nil {
+		return ""
+	}
+	if filepath.IsAbs(a.Build.Context) {
+		return a.Build.Context
+	}
+	return filepath.Join(baseDir, a.Build.Context)
+}
+
+// Dockerfile returns the Dockerfile path relative to the build context
+func (a *App) Dockerfile() string {
+	if a.Build == nil || a.Build.Dockerfile == "" {
+		return "Dockerfile"
+	}
+	return a.Build.Dockerfile
+}
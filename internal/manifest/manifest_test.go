@@ -0,0 +1,633 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+	"github.com/helmcode/coderun-cli/internal/utils"
+)
+
+func TestParseStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{
+			name:     "unknown top-level key",
+			manifest: "version: 1\nap:\n  name: web-app\n",
+			wantErr:  `coderun.yaml:2:1: unknown key "ap"`,
+		},
+		{
+			name:     "unknown app key",
+			manifest: "version: 1\napp:\n  name: web-app\n  image: nginx:1\n  imgae: nginx:2\n",
+			wantErr:  `coderun.yaml:5:3: unknown key "imgae"`,
+		},
+		{
+			name:     "unknown nested key",
+			manifest: "version: 1\napp:\n  name: web-app\n  build:\n    context: .\n    dockerfil: Dockerfile\n",
+			wantErr:  `coderun.yaml:6:5: unknown key "dockerfil"`,
+		},
+		{
+			name:     "every unknown key is reported",
+			manifest: "version: 1\napp:\n  name: web-app\n  image: nginx:1\n  port: 80\n  cpus: 1\n",
+			wantErr:  "coderun.yaml:5:3: unknown key \"port\"\ncoderun.yaml:6:3: unknown key \"cpus\"",
+		},
+		{
+			name:     "wrong type",
+			manifest: "version: 1\napp:\n  name: web-app\n  image: nginx:1\n  replicas: many\n",
+			wantErr:  "coderun.yaml:5: cannot unmarshal !!str `many` into int",
+		},
+		{
+			name:     "unsupported version",
+			manifest: "version: 2\napp:\n  name: web-app\n  image: nginx:1\n",
+			wantErr:  "coderun.yaml:1:10: unsupported manifest version 2 (expected 1)",
+		},
+		{
+			name:     "invalid app name",
+			manifest: "version: 1\napp:\n  name: Web_App\n  image: nginx:1\n",
+			wantErr:  "coderun.yaml:3:9: app name must contain only lowercase letters, numbers, and hyphens",
+		},
+		{
+			name:     "no image source",
+			manifest: "version: 1\napp:\n  name: web-app\n",
+			wantErr:  `coderun.yaml:3:3: either "image" or "build" is required`,
+		},
+		{
+			name:     "image and build",
+			manifest: "version: 1\napp:\n  name: web-app\n  image: nginx:1\n  build:\n    context: .\n",
+			wantErr:  `coderun.yaml:6:5: "image" and "build" cannot be used together`,
+		},
+		{
+			name:     "storage with replicas",
+			manifest: "version: 1\napp:\n  name: web-app\n  image: nginx:1\n  storage:\n    size: 1Gi\n    path: /data\n  replicas: 2\n",
+			wantErr:  "coderun.yaml:8:13: replicas must be 1 when persistent storage is configured",
+		},
+		{
+			name:     "duplicate env group",
+			manifest: "version: 1\napp:\n  name: web-app\n  image: nginx:1\n  env_groups: [shared, flags, shared]\n",
+			wantErr:  `coderun.yaml:5:31: duplicate env group "shared"`,
+		},
+		{
+			name:     "invalid YAML",
+			manifest: "version: 1\napp: [\n",
+			wantErr:  "coderun.yaml: line 2: did not find expected node content",
+		},
+		{
+			name:     "empty manifest",
+			manifest: "",
+			wantErr:  "coderun.yaml: manifest is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.manifest), "coderun.yaml")
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseApp(t *testing.T) {
+	m, err := Parse([]byte(`version: 1
+app:
+  name: web-app
+  image: nginx:1
+  http_port: 8080
+  replicas: 2
+  env:
+    LOG_LEVEL: info
+`), "coderun.yaml")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	spec := m.App.spec()
+	if spec.AppName != "web-app" || spec.Image != "nginx:1" || spec.Replicas != 2 {
+		t.Fatalf("spec = %+v", spec)
+	}
+	if spec.HTTPPort == nil || *spec.HTTPPort != 8080 {
+		t.Fatalf("http port = %v, want 8080", spec.HTTPPort)
+	}
+	if m.App.Env["LOG_LEVEL"] != "info" {
+		t.Fatalf("env = %v", m.App.Env)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{
+			name: "valid graph",
+			manifest: `version: 1
+apps:
+  - name: web
+    image: web:1
+    depends_on: [api, cache]
+  - name: api
+    image: api:1
+    depends_on: [database]
+  - name: cache
+    image: redis:7
+  - name: database
+    image: postgres:16
+`,
+		},
+		{
+			name:     "unknown dependency",
+			manifest: "version: 1\napps:\n  - name: web\n    image: web:1\n    depends_on: [api]\n",
+			wantErr:  `coderun.yaml:5:18: app "web" depends on unknown app "api"`,
+		},
+		{
+			name:     "depends on itself",
+			manifest: "version: 1\napps:\n  - name: web\n    image: web:1\n    depends_on: [web]\n",
+			wantErr:  `coderun.yaml:5:18: app "web" depends on itself`,
+		},
+		{
+			name: "cycle",
+			manifest: `version: 1
+apps:
+  - name: web
+    image: web:1
+    depends_on: [cache]
+  - name: api
+    image: api:1
+    depends_on: [web]
+  - name: cache
+    image: redis:7
+    depends_on: [api]
+`,
+			wantErr: "coderun.yaml:5:17: dependency cycle: web -> cache -> api -> web",
+		},
+		{
+			name:     "duplicate name",
+			manifest: "version: 1\napps:\n  - name: web\n    image: web:1\n  - name: web\n    image: web:2\n",
+			wantErr:  `coderun.yaml:5:11: duplicate app name "web"`,
+		},
+		{
+			name:     "depends_on in a single-app manifest",
+			manifest: "version: 1\napp:\n  name: web\n  image: web:1\n  depends_on: [api]\n",
+			wantErr:  `coderun.yaml:5:15: depends_on requires a multi-app manifest ("apps")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.manifest), "coderun.yaml")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	app := func(name string, deps ...string) *App {
+		return &App{Name: name, DependsOn: deps}
+	}
+
+	tests := []struct {
+		name string
+		apps []*App
+		want []string
+	}{
+		{"no dependencies", []*App{app("a"), app("b")}, nil},
+		{"chain", []*App{app("a", "b"), app("b", "c"), app("c")}, nil},
+		{"diamond", []*App{app("a", "b", "c"), app("b", "d"), app("c", "d"), app("d")}, nil},
+		{"two-app cycle", []*App{app("a", "b"), app("b", "a")}, []string{"a", "b", "a"}},
+		{"cycle behind a chain", []*App{app("a", "b"), app("b", "c"), app("c", "d"), app("d", "b")}, []string{"b", "c", "d", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findCycle(tt.apps)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("findCycle = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// writeManifest writes a manifest file into dir and returns its path
+func writeManifest(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOverlay(t *testing.T) {
+	base := `version: 1
+apps:
+  - name: web
+    image: web:1
+    replicas: 1
+    env:
+      LOG_LEVEL: info
+      DEBUG: "true"
+    depends_on: [api]
+  - name: api
+    image: api:1
+    cpu_limit: 500m
+`
+
+	tests := []struct {
+		name    string
+		overlay string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "named list items merge by name",
+			overlay: `apps:
+  - name: api
+    image: api:2
+  - name: web
+    replicas: 3
+    env:
+      LOG_LEVEL: warn
+`,
+			want: `version: 1
+apps:
+  - name: web
+    image: web:1
+    replicas: 3
+    env:
+      LOG_LEVEL: warn
+      DEBUG: "true"
+    depends_on: [api]
+  - name: api
+    image: api:2
+    cpu_limit: 500m
+`,
+		},
+		{
+			name: "null removes a key",
+			overlay: `apps:
+  - name: web
+    env:
+      DEBUG: null
+  - name: api
+    cpu_limit: ~
+`,
+			want: `version: 1
+apps:
+  - name: web
+    image: web:1
+    replicas: 1
+    env:
+      LOG_LEVEL: info
+    depends_on: [api]
+  - name: api
+    image: api:1
+`,
+		},
+		{
+			name: "new items are appended and plain lists are replaced",
+			overlay: `apps:
+  - name: web
+    depends_on: [api, worker]
+  - name: worker
+    image: worker:1
+`,
+			want: `version: 1
+apps:
+  - name: web
+    image: web:1
+    replicas: 1
+    env:
+      LOG_LEVEL: info
+      DEBUG: "true"
+    depends_on: [api, worker]
+  - name: api
+    image: api:1
+    cpu_limit: 500m
+  - name: worker
+    image: worker:1
+`,
+		},
+		{
+			name:    "overlay must be a mapping",
+			overlay: "- name: web\n",
+			wantErr: "coderun.staging.yaml:1:1: overlay must be a mapping",
+		},
+		{
+			name:    "errors point at the overlay file",
+			overlay: "apps:\n  - name: web\n    replica: 3\n",
+			wantErr: `coderun.staging.yaml:3:5: unknown key "replica"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writeManifest(t, dir, DefaultFile, base)
+			overlay := writeManifest(t, dir, "coderun.staging.yaml", tt.overlay)
+			opts := Options{Overlays: []string{overlay}}
+
+			if tt.wantErr != "" {
+				_, err := Load(path, opts)
+				if err == nil || !strings.HasSuffix(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to end with %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			rendered, err := Render(path, opts)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if string(rendered) != tt.want {
+				t.Fatalf("rendered:\n%s\nwant:\n%s", rendered, tt.want)
+			}
+			if _, err := Load(path, opts); err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+		})
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		env := map[string]string{"TAG": "v2", "EMPTY": "", "REGISTRY": "ghcr.io/acme"}
+		value, ok := env[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		name    string
+		image   string
+		vars    map[string]string
+		want    string
+		wantErr string
+	}{
+		{name: "variable", image: "web:${TAG}", want: "web:v2"},
+		{name: "several variables", image: "${REGISTRY}/web:${TAG}", want: "ghcr.io/acme/web:v2"},
+		{name: "default when unset", image: "web:${UNSET:-latest}", want: "web:latest"},
+		{name: "default when empty", image: "web:${EMPTY:-latest}", want: "web:latest"},
+		{name: "value over default", image: "web:${TAG:-latest}", want: "web:v2"},
+		{name: "empty default", image: "web${UNSET:-}", want: "web"},
+		{name: "empty without default", image: "web${EMPTY}", want: "web"},
+		{name: "--var before the environment", image: "web:${TAG}", vars: map[string]string{"TAG": "v3"}, want: "web:v3"},
+		{name: "escaped dollar", image: "web:$${TAG}", want: "web:${TAG}"},
+		{name: "bare dollar is kept", image: "web:$TAG", want: "web:$TAG"},
+		{name: "unset without default", image: "web:${UNSET}", wantErr: "coderun.yaml:4:10: variable UNSET is not set (use --var UNSET=... or ${UNSET:-default})"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeManifest(t, t.TempDir(), DefaultFile, "version: 1\napp:\n  name: web\n  image: "+tt.image+"\n")
+			m, err := Load(path, Options{Vars: tt.vars, LookupEnv: lookup})
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasSuffix(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to end with %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if m.App.Image != tt.want {
+				t.Fatalf("image = %q, want %q", m.App.Image, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpolateTypes(t *testing.T) {
+	path := writeManifest(t, t.TempDir(), DefaultFile, `version: 1
+app:
+  name: web
+  image: web:1
+  replicas: ${REPLICAS:-2}
+  env:
+    QUOTED: "${REPLICAS:-2}"
+`)
+	m, err := Load(path, Options{LookupEnv: func(string) (string, bool) { return "", false }})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.App.Replicas == nil || *m.App.Replicas != 2 {
+		t.Fatalf("replicas = %v, want 2", m.App.Replicas)
+	}
+	if m.App.Env["QUOTED"] != "2" {
+		t.Fatalf("QUOTED = %q, want \"2\"", m.App.Env["QUOTED"])
+	}
+}
+
+func TestRenderMasksSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+	}{
+		{
+			name: "single app",
+			manifest: `version: 1
+app:
+  name: web-app
+  image: nginx:1
+  env:
+    LOG_LEVEL: info
+  secrets:
+    DB_PASSWORD: ${DB_PASSWORD}
+    API_TOKEN: literal-token
+`,
+		},
+		{
+			name: "list of apps",
+			manifest: `version: 1
+apps:
+  - name: web-app
+    image: nginx:1
+    secrets:
+      DB_PASSWORD: ${DB_PASSWORD}
+  - name: worker
+    image: worker:1
+    secrets:
+      API_TOKEN: literal-token
+      DB_PASSWORD: prefix-${DB_PASSWORD}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DefaultFile)
+			if err := os.WriteFile(path, []byte(tt.manifest), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			opts := Options{LookupEnv: func(name string) (string, bool) {
+				if name == "DB_PASSWORD" {
+					return "hunter2", true
+				}
+				return "", false
+			}}
+			rendered, err := Render(path, opts)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+
+			output := string(rendered)
+			for _, secret := range []string{"hunter2", "literal-token"} {
+				if strings.Contains(output, secret) {
+					t.Errorf("rendered manifest contains the secret %q:\n%s", secret, output)
+				}
+			}
+			if !strings.Contains(output, "DB_PASSWORD: '"+utils.MaskedValue+"'") {
+				t.Errorf("rendered manifest does not mask DB_PASSWORD:\n%s", output)
+			}
+
+			// Loading still sees the real values
+			m, err := Load(path, opts)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if got := m.AllApps()[0].Secrets["DB_PASSWORD"]; got != "hunter2" {
+				t.Errorf("loaded DB_PASSWORD = %q, want hunter2", got)
+			}
+		})
+	}
+}
+
+func TestParseEnvSchema(t *testing.T) {
+	m, err := Parse([]byte(`version: 1
+app:
+  name: web-app
+  image: nginx:1
+  env:
+    LOG_LEVEL: info
+  env_schema:
+    OPTIONAL:
+    LOG_LEVEL: {type: enum, values: [debug, info]}
+    PORT: {required: true, pattern: "[0-9]+"}
+`), DefaultFile)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	schema := m.App.EnvSchema
+	if rule, ok := schema["OPTIONAL"]; !ok || rule == nil {
+		t.Fatalf("bare OPTIONAL: rule = %v, want an empty rule", rule)
+	}
+	err = schema.Validate(map[string]string{"LOG_LEVEL": "info", "PORT": "x80"}, nil)
+	if err == nil || err.Error() != "PORT: does not match pattern [0-9]+" {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	_, err = Parse([]byte(`version: 1
+app:
+  name: web-app
+  image: nginx:1
+  env_schema:
+    LOG_LEVEL: {type: enum}
+`), DefaultFile)
+	if err == nil || err.Error() != "coderun.yaml:6:16: env_schema LOG_LEVEL: type enum requires values" {
+		t.Fatalf("invalid rule: %v", err)
+	}
+}
+
+func TestInterpolateKeepsStrings(t *testing.T) {
+	path := writeManifest(t, t.TempDir(), DefaultFile, `version: 1
+app:
+  name: web
+  image: web:1
+  replicas: ${REPLICAS}
+  http_port: ${PORT:-8080}
+  env:
+    TILDE: ${TILDE}
+    NOTHING: ${NULL_VALUE}
+    BOOL: ${BOOL}
+    OCTAL: ${OCTAL}
+    NUMBER: ${REPLICAS}
+`)
+	vars := map[string]string{"REPLICAS": "3", "TILDE": "~", "NULL_VALUE": "null", "BOOL": "true", "OCTAL": "0123"}
+	opts := Options{Vars: vars, LookupEnv: func(string) (string, bool) { return "", false }}
+
+	m, err := Load(path, opts)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := map[string]string{"TILDE": "~", "NOTHING": "null", "BOOL": "true", "OCTAL": "0123", "NUMBER": "3"}
+	for key, value := range want {
+		if got, ok := m.App.Env[key]; !ok || got != value {
+			t.Errorf("%s = %q (set %v), want %q", key, got, ok, value)
+		}
+	}
+	if m.App.Replicas == nil || *m.App.Replicas != 3 {
+		t.Errorf("replicas = %v, want 3", m.App.Replicas)
+	}
+	if m.App.HTTPPort == nil || *m.App.HTTPPort != 8080 {
+		t.Errorf("http_port = %v, want 8080", m.App.HTTPPort)
+	}
+
+	// The rendered manifest quotes the strings and loads back the same way
+	rendered, err := Render(path, opts)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for _, line := range []string{"replicas: 3", "http_port: 8080", `TILDE: "~"`, `NOTHING: "null"`, `BOOL: "true"`, `OCTAL: "0123"`, `NUMBER: "3"`} {
+		if !strings.Contains(string(rendered), line) {
+			t.Errorf("rendered manifest has no %q:\n%s", line, rendered)
+		}
+	}
+	again, err := Parse(rendered, DefaultFile)
+	if err != nil {
+		t.Fatalf("Parse(rendered): %v", err)
+	}
+	if again.App.Env["OCTAL"] != "0123" || again.App.Env["TILDE"] != "~" {
+		t.Errorf("rendered manifest changed values: %v", again.App.Env)
+	}
+}
+
+func TestFromDeploymentRoundTrip(t *testing.T) {
+	httpPort := 8080
+	live := &client.DeploymentResponse{
+		ID:              "d-1",
+		AppName:         "web-app",
+		Image:           "web:1",
+		Replicas:        2,
+		CPULimit:        "500m",
+		MemoryLimit:     "512Mi",
+		HTTPPort:        &httpPort,
+		EnvironmentVars: map[string]string{"LOG_LEVEL": "info", "PRICE": "$5"},
+		EnvGroups:       []string{"shared", "flags"},
+	}
+
+	data, err := Marshal(&Manifest{Version: CurrentVersion, App: FromDeployment(live, false)})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	dir := t.TempDir()
+	m, err := Load(writeManifest(t, dir, "coderun.yaml", string(data)), Options{})
+	if err != nil {
+		t.Fatalf("exported manifest does not load: %v\n%s", err, data)
+	}
+	spec, err := m.App.DeploymentCreate(dir)
+	if err != nil {
+		t.Fatalf("DeploymentCreate: %v", err)
+	}
+
+	if changes := utils.DiffDeployment(live, spec); len(changes) > 0 {
+		t.Errorf("exported manifest differs from the deployment: %+v\n%s", changes, data)
+	}
+	if changes := utils.DiffEnvGroups(live.EnvGroups, m.App.EnvGroups); len(changes) > 0 {
+		t.Errorf("exported env groups differ from the deployment: %+v\n%s", changes, data)
+	}
+}
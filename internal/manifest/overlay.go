@@ -0,0 +1,114 @@
+package manifest
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// merge applies an overlay on top of the document:
+//   - mappings are merged key by key
+//   - lists of named mappings (such as apps) are merged item by item, matched by name
+//   - any other value (scalar or list) in the overlay replaces the base value
+//   - a null value in the overlay removes the key
+func (d *document) merge(overlay *document) error {
+	for node, filename := range overlay.files {
+		d.files[node] = filename
+	}
+
+	if overlay.root.Kind != yaml.MappingNode {
+		return overlay.errorAt("overlay must be a mapping")
+	}
+
+	d.root = mergeNodes(d.root, overlay.root)
+	return nil
+}
+
+// mergeNodes merges overlay into base and returns the resulting node
+func mergeNodes(base, overlay *yaml.Node) *yaml.Node {
+	switch {
+	case base.Kind == yaml.MappingNode && overlay.Kind == yaml.MappingNode:
+		mergeMappings(base, overlay)
+		return base
+	case base.Kind == yaml.SequenceNode && overlay.Kind == yaml.SequenceNode && isNamedList(base) && isNamedList(overlay):
+		mergeNamedLists(base, overlay)
+		return base
+	default:
+		return overlay
+	}
+}
+
+// mergeMappings merges the keys of overlay into base
+func mergeMappings(base, overlay *yaml.Node) {
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+
+		index := mappingIndex(base, key.Value)
+		switch {
+		case isNull(value):
+			if index >= 0 {
+				base.Content = append(base.Content[:index], base.Content[index+2:]...)
+			}
+		case index >= 0:
+			base.Content[index+1] = mergeNodes(base.Content[index+1], value)
+		default:
+			base.Content = append(base.Content, key, value)
+		}
+	}
+}
+
+// mergeNamedLists merges items of overlay into base, matching them by their name key
+func mergeNamedLists(base, overlay *yaml.Node) {
+	for _, item := range overlay.Content {
+		name := nameOf(item)
+		merged := false
+		for i, baseItem := range base.Content {
+			if nameOf(baseItem) == name {
+				base.Content[i] = mergeNodes(baseItem, item)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			base.Content = append(base.Content, item)
+		}
+	}
+}
+
+// mappingIndex returns the index of key in a mapping node's content, or -1
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// isNamedList reports whether every item of a sequence is a mapping with a name
+func isNamedList(node *yaml.Node) bool {
+	if len(node.Content) == 0 {
+		return false
+	}
+	for _, item := range node.Content {
+		if nameOf(item) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// nameOf returns the scalar "name" value of a mapping node, or ""
+func nameOf(node *yaml.Node) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	index := mappingIndex(node, "name")
+	if index < 0 || node.Content[index+1].Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Content[index+1].Value
+}
+
+// isNull reports whether a node is an explicit null
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
@@ -0,0 +1,243 @@
+package manifest
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+)
+
+// SchemaID identifies the published manifest schema
+const SchemaID = "https://raw.githubusercontent.com/helmcode/coderun-cli/main/schema/coderun.schema.json"
+
+// interpolationPattern matches values that are filled in by ${VAR} interpolation
+const interpolationPattern = `\$\{[A-Za-z_][A-Za-z0-9_]*(:-[^}]*)?\}`
+
+// quantityPattern matches resource quantities as parsed by utils.ParseQuantity
+const quantityPattern = `^\+?([0-9]+(\.[0-9]*)?|\.[0-9]+)([KMGTPE]i|[numkMGTPE]|[eE][+-]?[0-9]+)?$`
+
+// fieldRules are the local validation rules, keyed by manifest (yaml) or API (json) field name.
+// They mirror utils.ValidateDeploymentSpec.
+var fieldRules = map[string]map[string]interface{}{
+	"name":                         {"minLength": 3, "maxLength": 30, "pattern": `^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`},
+	"app_name":                     {"minLength": 3, "maxLength": 30, "pattern": `^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`},
+	"image":                        {"minLength": 1},
+	"replicas":                     {"minimum": 0},
+	"cpu_limit":                    {"pattern": quantityPattern},
+	"cpu_request":                  {"pattern": quantityPattern},
+	"memory_limit":                 {"pattern": quantityPattern},
+	"memory_request":               {"pattern": quantityPattern},
+	"http_port":                    {"minimum": 1, "maximum": 65535},
+	"tcp_port":                     {"minimum": 1, "maximum": 65535},
+	"size":                         {"pattern": quantityPattern},
+	"persistent_volume_size":       {"pattern": quantityPattern},
+	"path":                         {"pattern": `^/`},
+	"persistent_volume_mount_path": {"pattern": `^/`},
+	"version":                      {"const": CurrentVersion},
+	"type":                         {"enum": []string{"string", "int", "bool", "url", "enum"}},
+	"values":                       {"minItems": 1},
+}
+
+// fieldDescriptions document fields for editor hovers, keyed like fieldRules
+var fieldDescriptions = map[string]string{
+	"version":                      "Manifest format version",
+	"app":                          "A single application",
+	"apps":                         "Several applications, deployed in dependency order",
+	"name":                         "Application name: 3-30 characters, lowercase letters, numbers and hyphens",
+	"app_name":                     "Application name: 3-30 characters, lowercase letters, numbers and hyphens",
+	"image":                        "Container image to deploy",
+	"build":                        "Build the image from source instead of using an existing image",
+	"context":                      "Build context directory, relative to the manifest",
+	"dockerfile":                   "Path to the Dockerfile, relative to the build context",
+	"replicas":                     "Number of replicas",
+	"cpu_limit":                    "CPU limit (e.g. 100m, 0.5)",
+	"cpu_request":                  "CPU request (e.g. 100m, 0.5)",
+	"memory_limit":                 "Memory limit (e.g. 128Mi, 1Gi, 512M)",
+	"memory_request":               "Memory request (e.g. 128Mi, 1Gi, 512M)",
+	"http_port":                    "HTTP port to expose (cannot be combined with tcp_port)",
+	"tcp_port":                     "TCP port to expose (cannot be combined with http_port)",
+	"env":                          "Environment variables; they override env_file",
+	"environment_vars":             "Environment variables",
+	"env_file":                     "Environment file, relative to the manifest",
+	"secrets":                      "Secret environment variables, never shown in output; usually ${VAR} references",
+	"secret_vars":                  "Secret environment variables; write-only",
+	"storage":                      "Persistent volume (requires a single replica)",
+	"size":                         "Persistent volume size (e.g. 1Gi, 500Mi)",
+	"path":                         "Absolute mount path of the persistent volume",
+	"persistent_volume_size":       "Persistent volume size (e.g. 1Gi, 500Mi)",
+	"persistent_volume_mount_path": "Absolute mount path of the persistent volume",
+	"depends_on":                   "Apps that must be deployed and ready first",
+	"env_groups":                   "Env groups the app uses, in order; later groups override earlier ones and env and secrets override groups",
+	"env_schema":                   "Rules the merged env and secrets must satisfy before deploying, keyed by variable name",
+	"required":                     "The variable must be set and not empty",
+	"type":                         "Value type: string (default), int, bool, url or enum",
+	"values":                       "Allowed values of an enum variable",
+	"pattern":                      "Regular expression the whole value must match",
+	"description":                  "What the variable is for",
+}
+
+// typeRules add constraints that involve several fields of a type
+var typeRules = map[reflect.Type]map[string]interface{}{
+	reflect.TypeOf(Manifest{}): {
+		"oneOf": []interface{}{
+			map[string]interface{}{"required": []string{"app"}},
+			map[string]interface{}{"required": []string{"apps"}},
+		},
+	},
+	reflect.TypeOf(App{}): {
+		"oneOf": []interface{}{
+			map[string]interface{}{"required": []string{"image"}},
+			map[string]interface{}{"required": []string{"build"}},
+		},
+		"not": map[string]interface{}{"required": []string{"http_port", "tcp_port"}},
+		"if":  map[string]interface{}{"required": []string{"storage"}},
+		"then": map[string]interface{}{
+			"properties": map[string]interface{}{"replicas": map[string]interface{}{"maximum": 1}},
+		},
+	},
+	reflect.TypeOf(client.DeploymentCreate{}): {
+		"not": map[string]interface{}{"required": []string{"http_port", "tcp_port"}},
+		"dependentRequired": map[string]interface{}{
+			"persistent_volume_size":       []string{"persistent_volume_mount_path"},
+			"persistent_volume_mount_path": []string{"persistent_volume_size"},
+		},
+	},
+}
+
+// Schema returns the JSON Schema for manifest files, generated from the manifest types.
+// The API request type client.DeploymentCreate is included under $defs.
+func Schema() map[string]interface{} {
+	g := &schemaGenerator{defs: make(map[string]interface{})}
+
+	root := g.object(reflect.TypeOf(Manifest{}), "yaml")
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = SchemaID
+	root["title"] = "CodeRun manifest"
+
+	g.defs["DeploymentCreate"] = g.object(reflect.TypeOf(client.DeploymentCreate{}), "json")
+	g.defs["Interpolation"] = map[string]interface{}{
+		"type":        "string",
+		"pattern":     interpolationPattern,
+		"description": "A value filled in by ${VAR} or ${VAR:-default} interpolation",
+	}
+	root["$defs"] = g.defs
+
+	return root
+}
+
+// schemaGenerator builds schemas from Go types, collecting named structs under $defs
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+// object returns the schema of a struct type using the given struct tag for field names
+func (g *schemaGenerator) object(t reflect.Type, tagName string) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get(tagName), ",")
+		name := tag[0]
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		schema := g.typeSchema(field.Type, tagName)
+		for key, value := range fieldRules[name] {
+			schema[key] = value
+		}
+		if name == "apps" {
+			schema["minItems"] = 1
+		}
+
+		// Manifest values may also be filled in by interpolation
+		if tagName == "yaml" && isScalar(field.Type) && (fieldRules[name] != nil || schema["type"] != "string") {
+			schema = map[string]interface{}{
+				"anyOf": []interface{}{schema, map[string]interface{}{"$ref": "#/$defs/Interpolation"}},
+			}
+		}
+
+		if description, ok := fieldDescriptions[name]; ok {
+			schema["description"] = description
+		}
+		properties[name] = schema
+
+		if !hasOption(tag, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	for key, value := range typeRules[t] {
+		schema[key] = value
+	}
+
+	return schema
+}
+
+// typeSchema returns the schema of a field type
+func (g *schemaGenerator) typeSchema(t reflect.Type, tagName string) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // reserve the name to stop recursion
+			g.defs[t.Name()] = g.object(t, tagName)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": g.typeSchema(t.Elem(), tagName)}
+	case reflect.Map:
+		values := g.typeSchema(t.Elem(), tagName)
+		if tagName == "yaml" && t.Elem().Kind() == reflect.String {
+			// YAML scalars such as 8080 or true are read as strings
+			values = map[string]interface{}{"type": []string{"string", "number", "boolean"}}
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+
+	return map[string]interface{}{}
+}
+
+// isScalar reports whether a field holds a single value
+func isScalar(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func hasOption(tag []string, option string) bool {
+	for _, opt := range tag[1:] {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// CommandResolver runs a shell command and uses its output as the secret:
+// cmd://pass show app/db. The command's stderr and stdin are the terminal's,
+// so password managers can prompt. A single trailing newline is removed.
+type CommandResolver struct{}
+
+// Resolve runs ref with the system shell
+func (CommandResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	if strings.TrimSpace(ref) == "" {
+		return "", fmt.Errorf("missing command")
+	}
+
+	var command *exec.Cmd
+	if runtime.GOOS == "windows" {
+		command = exec.CommandContext(ctx, "cmd", "/C", ref)
+	} else {
+		command = exec.CommandContext(ctx, "sh", "-c", ref)
+	}
+
+	var stdout bytes.Buffer
+	command.Stdout = &stdout
+	command.Stderr = os.Stderr
+	command.Stdin = os.Stdin
+	if err := command.Run(); err != nil {
+		return "", fmt.Errorf("command failed: %w", err)
+	}
+	return trimNewline(stdout.String()), nil
+}
@@ -0,0 +1,31 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FileResolver reads secrets from files, as mounted by Docker or Kubernetes:
+// file:///run/secrets/db_password. A single trailing newline is removed.
+type FileResolver struct{}
+
+// Resolve reads the file at ref
+func (FileResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	if ref == "" {
+		return "", fmt.Errorf("missing file path")
+	}
+
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return trimNewline(string(data)), nil
+}
+
+// trimNewline removes one trailing line ending, as left by editors and echo
+func trimNewline(s string) string {
+	s = strings.TrimSuffix(s, "\n")
+	return strings.TrimSuffix(s, "\r")
+}
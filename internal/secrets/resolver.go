@@ -0,0 +1,141 @@
+// Package secrets resolves secret references in environment values, such as
+// vault://secret/data/app#db_password, file:///run/secrets/x or cmd://pass show foo,
+// so that env files never need to contain the secrets themselves.
+//
+// Resolved values are returned to the caller only; they are never logged or
+// included in errors.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Resolver resolves references of one scheme to their secret values
+type Resolver interface {
+	// Resolve returns the value a reference points to. ref is the part after "scheme://".
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface
+type ResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve calls f(ctx, ref)
+func (f ResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// Registry maps reference schemes (without "://") to their resolvers
+type Registry map[string]Resolver
+
+// DefaultRegistry returns the resolvers for vault://, file:// and cmd:// references.
+// The Vault resolver is configured from VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE.
+func DefaultRegistry() Registry {
+	return Registry{
+		"vault": NewVaultResolverFromEnv(),
+		"file":  FileResolver{},
+		"cmd":   CommandResolver{},
+	}
+}
+
+// ErrUnknownScheme is returned for references whose scheme has no resolver
+var ErrUnknownScheme = errors.New("no resolver for scheme")
+
+// Error is a failure to resolve the reference of one variable.
+// It names the variable and the reference, never a value.
+type Error struct {
+	Key string
+	Ref string
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: cannot resolve %s: %v", e.Key, e.Ref, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Reference is a resolved variable, as reported to the user
+type Reference struct {
+	Key string
+	Ref string
+}
+
+// escapePrefix marks a value that starts with a reference scheme as literal text,
+// e.g. \file:///data for the value file:///data
+const escapePrefix = `\`
+
+// Parse splits a value into its scheme and reference when it is a reference to
+// one of the registry's schemes
+func (r Registry) Parse(value string) (scheme, ref string, ok bool) {
+	scheme, ref, found := strings.Cut(value, "://")
+	if !found {
+		return "", "", false
+	}
+	if _, known := r[scheme]; !known {
+		return "", "", false
+	}
+	return scheme, ref, true
+}
+
+// IsReference reports whether a value is a reference to one of the registry's schemes
+func (r Registry) IsReference(value string) bool {
+	_, _, ok := r.Parse(value)
+	return ok
+}
+
+// unescape returns the literal text of an escaped reference
+func (r Registry) unescape(value string) (string, bool) {
+	literal, found := strings.CutPrefix(value, escapePrefix)
+	if !found || !r.IsReference(literal) {
+		return value, false
+	}
+	return literal, true
+}
+
+// ResolveEnv replaces every reference in envVars with the value it points to, and
+// escaped references with their literal text. It resolves all references before
+// returning, reporting every failure at once, and leaves envVars unchanged when any
+// reference fails.
+func (r Registry) ResolveEnv(ctx context.Context, envVars map[string]string) ([]Reference, error) {
+	keys := make([]string, 0, len(envVars))
+	for key := range envVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	resolved := make(map[string]string)
+	var refs []Reference
+	var errs []error
+	for _, key := range keys {
+		if literal, escaped := r.unescape(envVars[key]); escaped {
+			resolved[key] = literal
+			continue
+		}
+		scheme, ref, ok := r.Parse(envVars[key])
+		if !ok {
+			continue
+		}
+
+		value, err := r[scheme].Resolve(ctx, ref)
+		if err != nil {
+			errs = append(errs, &Error{Key: key, Ref: envVars[key], Err: err})
+			continue
+		}
+		resolved[key] = value
+		refs = append(refs, Reference{Key: key, Ref: envVars[key]})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	for key, value := range resolved {
+		envVars[key] = value
+	}
+	return refs, nil
+}
@@ -0,0 +1,154 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultResolver reads secrets from a HashiCorp Vault KV v2 engine over HTTP.
+//
+// A reference is the API path of the secret and the field to read:
+// vault://secret/data/app#db_password reads the db_password field of the latest
+// version of secret/app on the "secret" mount. Add ?version=N to read a specific
+// version. Each secret is fetched once, however many fields are read from it.
+type VaultResolver struct {
+	Address    string
+	Token      string
+	Namespace  string
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]map[string]interface{}
+}
+
+// NewVaultResolverFromEnv configures a resolver from VAULT_ADDR, VAULT_TOKEN and
+// VAULT_NAMESPACE, falling back to the token saved by 'vault login' in ~/.vault-token
+func NewVaultResolverFromEnv() *VaultResolver {
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			if data, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+				token = strings.TrimSpace(string(data))
+			}
+		}
+	}
+
+	return &VaultResolver{
+		Address:   os.Getenv("VAULT_ADDR"),
+		Token:     token,
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// vaultResponse is the part of a Vault read response the resolver uses
+type vaultResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []string               `json:"errors"`
+}
+
+// Resolve reads one field of a secret
+func (v *VaultResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("expected vault://<mount>/data/<path>#<field>")
+	}
+	if v.Address == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+	if v.Token == "" {
+		return "", fmt.Errorf("VAULT_TOKEN is not set and there is no ~/.vault-token")
+	}
+
+	data, err := v.read(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %q", field)
+	}
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case nil:
+		return "", fmt.Errorf("field %q is null", field)
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("field %q: %w", field, err)
+		}
+		return string(encoded), nil
+	}
+}
+
+// read fetches the key/value data of a secret, once per path
+func (v *VaultResolver) read(ctx context.Context, path string) (map[string]interface{}, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if data, ok := v.cache[path]; ok {
+		return data, nil
+	}
+
+	endpoint := strings.TrimSuffix(v.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret path: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	req.Header.Set("X-Vault-Request", "true")
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	httpClient := v.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to Vault failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body vaultResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != http.StatusOK {
+		if len(body.Errors) > 0 {
+			return nil, fmt.Errorf("vault returned %d: %s", resp.StatusCode, strings.Join(body.Errors, "; "))
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("secret not found")
+		}
+		return nil, fmt.Errorf("vault returned %d", resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("failed to decode Vault response: %w", decodeErr)
+	}
+
+	// KV v2 nests the key/value pairs under data.data, next to data.metadata
+	data := body.Data
+	if _, ok := data["metadata"]; ok {
+		data, _ = data["data"].(map[string]interface{})
+	}
+	if data == nil {
+		return nil, fmt.Errorf("secret not found or deleted")
+	}
+
+	if v.cache == nil {
+		v.cache = make(map[string]map[string]interface{})
+	}
+	v.cache[path] = data
+	return data, nil
+}
@@ -0,0 +1,201 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// vaultStub serves KV v2 secrets and counts requests per path and version
+type vaultStub struct {
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func newVaultStub(t *testing.T) (*httptest.Server, *vaultStub) {
+	stub := &vaultStub{requests: make(map[string]int)}
+	server := httptest.NewServer(http.HandlerFunc(stub.serve))
+	t.Cleanup(server.Close)
+	return server, stub
+}
+
+func (s *vaultStub) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.RequestURI()]++
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.Header.Get("X-Vault-Token") != "test-token" {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	switch r.URL.Path {
+	case "/v1/secret/data/app":
+		password := "current-password"
+		if r.URL.Query().Get("version") == "1" {
+			password = "old-password"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data": map[string]interface{}{
+					"db_password": password,
+					"api_key":     "key-123",
+					"ports":       []int{80, 443},
+				},
+				"metadata": map[string]interface{}{"version": 2},
+			},
+		})
+	case "/v1/kv/legacy":
+		// KV v1 returns the key/value pairs directly under data
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"token": "v1-token"},
+		})
+	case "/v1/secret/data/deleted":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     nil,
+				"metadata": map[string]interface{}{"deletion_time": "2024-01-01T00:00:00Z"},
+			},
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+	}
+}
+
+func (s *vaultStub) count(requestURI string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[requestURI]
+}
+
+func TestVaultResolver(t *testing.T) {
+	server, _ := newVaultStub(t)
+	resolver := &VaultResolver{Address: server.URL, Token: "test-token"}
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{name: "KV v2 data.data", ref: "secret/data/app#db_password", want: "current-password"},
+		{name: "specific version", ref: "secret/data/app?version=1#db_password", want: "old-password"},
+		{name: "non-string field is JSON", ref: "secret/data/app#ports", want: "[80,443]"},
+		{name: "KV v1", ref: "kv/legacy#token", want: "v1-token"},
+		{name: "missing field", ref: "secret/data/app#nope", wantErr: `secret has no field "nope"`},
+		{name: "not found", ref: "secret/data/missing#x", wantErr: "secret not found"},
+		{name: "deleted version", ref: "secret/data/deleted#x", wantErr: "secret not found or deleted"},
+		{name: "no field", ref: "secret/data/app", wantErr: "expected vault://<mount>/data/<path>#<field>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Resolve(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("value = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVaultResolverErrorsBody(t *testing.T) {
+	server, _ := newVaultStub(t)
+	resolver := &VaultResolver{Address: server.URL, Token: "wrong-token"}
+
+	_, err := resolver.Resolve(context.Background(), "secret/data/app#db_password")
+	if err == nil || err.Error() != "vault returned 403: permission denied" {
+		t.Fatalf("error = %v, want the errors of the response body", err)
+	}
+}
+
+func TestVaultResolverCache(t *testing.T) {
+	server, stub := newVaultStub(t)
+	resolver := &VaultResolver{Address: server.URL, Token: "test-token"}
+
+	refs := []string{
+		"secret/data/app#db_password",
+		"secret/data/app#api_key",
+		"secret/data/app#db_password",
+		"secret/data/app?version=1#db_password",
+		"secret/data/app?version=1#api_key",
+	}
+	for _, ref := range refs {
+		if _, err := resolver.Resolve(context.Background(), ref); err != nil {
+			t.Fatalf("Resolve(%s): %v", ref, err)
+		}
+	}
+
+	if n := stub.count("/v1/secret/data/app"); n != 1 {
+		t.Errorf("latest version fetched %d times, want 1", n)
+	}
+	if n := stub.count("/v1/secret/data/app?version=1"); n != 1 {
+		t.Errorf("version 1 fetched %d times, want 1", n)
+	}
+
+	// A new resolver, as in a new run, fetches again
+	fresh := &VaultResolver{Address: server.URL, Token: "test-token"}
+	if _, err := fresh.Resolve(context.Background(), "secret/data/app#db_password"); err != nil {
+		t.Fatal(err)
+	}
+	if n := stub.count("/v1/secret/data/app"); n != 2 {
+		t.Errorf("latest version fetched %d times after a new run, want 2", n)
+	}
+}
+
+func TestResolveEnvWithVault(t *testing.T) {
+	server, _ := newVaultStub(t)
+	registry := Registry{"vault": &VaultResolver{Address: server.URL, Token: "test-token"}}
+
+	env := map[string]string{
+		"DB_PASSWORD": "vault://secret/data/app#db_password",
+		"PLAIN":       "value",
+		"ESCAPED":     `\vault://secret/data/app#db_password`,
+		"BACKSLASH":   `\value`,
+	}
+	refs, err := registry.ResolveEnv(context.Background(), env)
+	if err != nil {
+		t.Fatalf("ResolveEnv: %v", err)
+	}
+	want := map[string]string{
+		"DB_PASSWORD": "current-password",
+		"PLAIN":       "value",
+		"ESCAPED":     "vault://secret/data/app#db_password",
+		"BACKSLASH":   `\value`,
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Fatalf("env = %v, want %v", env, want)
+	}
+	if len(refs) != 1 || refs[0].Key != "DB_PASSWORD" {
+		t.Fatalf("refs = %v", refs)
+	}
+
+	// Failures name the variable and the reference, but never a value, and leave env unchanged
+	env = map[string]string{
+		"A": "vault://secret/data/missing#x",
+		"B": "vault://secret/data/app#db_password",
+	}
+	_, err = registry.ResolveEnv(context.Background(), env)
+	if err == nil || !strings.Contains(err.Error(), "A: cannot resolve vault://secret/data/missing#x") {
+		t.Fatalf("error = %v", err)
+	}
+	if strings.Contains(err.Error(), "current-password") || env["B"] != "vault://secret/data/app#db_password" {
+		t.Fatalf("failed resolution leaked or changed values: %v, %v", err, env)
+	}
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// OpenBrowser opens a URL in the user's default browser
+func OpenBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+// RandomState generates a random value for the OAuth state parameter
+func RandomState() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// callbackResult holds the outcome of an authorization redirect
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// CallbackServer is a loopback HTTP listener that receives an OAuth authorization code
+type CallbackServer struct {
+	listener net.Listener
+	server   *http.Server
+	state    string
+	results  chan callbackResult
+}
+
+// StartCallbackServer listens on a random loopback port for the authorization redirect
+func StartCallbackServer(state string) (*CallbackServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start callback listener: %w", err)
+	}
+
+	cs := &CallbackServer{
+		listener: listener,
+		state:    state,
+		results:  make(chan callbackResult, 1),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", cs.handleCallback)
+	cs.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go cs.server.Serve(listener)
+
+	return cs, nil
+}
+
+// RedirectURI returns the callback URL to register with the authorization request
+func (cs *CallbackServer) RedirectURI() string {
+	return fmt.Sprintf("http://%s/callback", cs.listener.Addr().String())
+}
+
+// Wait blocks until the authorization code arrives or the context ends
+func (cs *CallbackServer) Wait(ctx context.Context) (string, error) {
+	select {
+	case result := <-cs.results:
+		return result.code, result.err
+	case <-ctx.Done():
+		return "", fmt.Errorf("timed out waiting for browser authorization")
+	}
+}
+
+// Close shuts down the callback listener
+func (cs *CallbackServer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return cs.server.Shutdown(ctx)
+}
+
+func (cs *CallbackServer) handleCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// Requests without our state did not come from the authorization server: reject them
+	// and keep waiting, so a stray request to the port cannot abort the login
+	if query.Get("state") != cs.state {
+		http.Error(w, "invalid state", http.StatusBadRequest)
+		return
+	}
+
+	var result callbackResult
+	switch {
+	case query.Get("error") != "":
+		result.err = fmt.Errorf("authorization failed: %s %s", query.Get("error"), query.Get("error_description"))
+	case query.Get("code") == "":
+		result.err = fmt.Errorf("authorization failed: no code in callback")
+	default:
+		result.code = query.Get("code")
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if result.err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, "<html><body><h2>CodeRun login failed</h2><p>You can close this window and check your terminal.</p></body></html>")
+	} else {
+		fmt.Fprint(w, "<html><body><h2>CodeRun login complete</h2><p>You can close this window and return to your terminal.</p></body></html>")
+	}
+
+	// Only the first callback counts
+	select {
+	case cs.results <- result:
+	default:
+	}
+}
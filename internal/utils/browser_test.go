@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+)
+
+// newAuthorizeServer is a stub authorization server that approves every login
+func newAuthorizeServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/auth/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		redirect, _ := url.Parse(query.Get("redirect_uri"))
+		params := url.Values{"code": {"auth-code"}, "state": {query.Get("state")}}
+		redirect.RawQuery = params.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/api/v1/auth/token", func(w http.ResponseWriter, r *http.Request) {
+		var req client.TokenRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		if req.Code != "auth-code" || req.CodeVerifier == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(client.LoginResponse{AccessToken: "web-token", TokenType: "bearer"})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBrowserLogin(t *testing.T) {
+	server := newAuthorizeServer(t)
+	apiClient := client.NewClient(server.URL)
+
+	verifier, challenge, err := client.NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := RandomState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	callback, err := StartCallbackServer(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer callback.Close()
+
+	// Requests with a wrong or missing state are rejected and do not end the login
+	for _, query := range []string{"?code=evil&state=wrong", "?error=access_denied"} {
+		resp, err := http.Get(callback.RedirectURI() + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: got HTTP %d, want 400", query, resp.StatusCode)
+		}
+	}
+
+	// The "browser" follows the authorization redirect back to the callback
+	resp, err := http.Get(apiClient.AuthorizationURL(callback.RedirectURI(), state, challenge))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("callback: got HTTP %d, want 200", resp.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	code, err := callback.Wait(ctx)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if code != "auth-code" {
+		t.Fatalf("code = %q, want auth-code", code)
+	}
+
+	loginResp, err := apiClient.ExchangeAuthorizationCode(code, verifier, callback.RedirectURI())
+	if err != nil {
+		t.Fatalf("ExchangeAuthorizationCode: %v", err)
+	}
+	if loginResp.AccessToken != "web-token" {
+		t.Fatalf("access token = %q, want web-token", loginResp.AccessToken)
+	}
+}
@@ -12,8 +12,9 @@ var DefaultAPIURL = "http://localhost:8000"
 
 // Config represents the CLI configuration
 type Config struct {
-	BaseURL     string `json:"base_url"`
-	AccessToken string `json:"access_token,omitempty"`
+	BaseURL      string `json:"base_url"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
 // GetDefaultAPIURL returns the default API URL, checking environment variables first
@@ -27,6 +28,11 @@ func GetDefaultAPIURL() string {
 	return DefaultAPIURL
 }
 
+// GetEnvToken returns the API token from the CODERUN_TOKEN environment variable, if set
+func GetEnvToken() string {
+	return os.Getenv("CODERUN_TOKEN")
+}
+
 // GetConfigPath returns the path to the configuration file
 func GetConfigPath() (string, error) {
 	homeDir, err := os.UserHomeDir()
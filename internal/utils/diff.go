@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+)
+
+// Change types reported by DiffDeployment
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// MaskedValue replaces secret values in output
+const MaskedValue = "****"
+
+// FieldChange describes one difference between a live deployment and a desired spec.
+// Values of environment variables are always masked.
+type FieldChange struct {
+	Field   string `json:"field"`
+	Type    string `json:"type"`
+	Live    string `json:"live,omitempty"`
+	Desired string `json:"desired,omitempty"`
+}
+
+// DiffDeployment compares a live deployment with a desired spec.
+// A nil live deployment means the app does not exist yet. An empty desired image is not compared,
+// since it may only be known after a build.
+func DiffDeployment(live *client.DeploymentResponse, desired *client.DeploymentCreate) []FieldChange {
+	if live == nil {
+		live = &client.DeploymentResponse{}
+	}
+
+	var changes []FieldChange
+	compare := func(field, liveValue, desiredValue string) {
+		if change, ok := diffValue(field, liveValue, desiredValue); ok {
+			changes = append(changes, change)
+		}
+	}
+	// Resource quantities are compared by value, so 1Gi and 1024Mi are not a change
+	compareQuantity := func(field, liveValue, desiredValue string) {
+		if !EqualQuantities(liveValue, desiredValue) {
+			compare(field, liveValue, desiredValue)
+		}
+	}
+
+	if desired.Image != "" {
+		compare("image", live.Image, desired.Image)
+	}
+	compare("replicas", formatReplicas(live.Replicas, live.ID != ""), formatReplicas(desired.Replicas, true))
+	compareQuantity("cpu_limit", live.CPULimit, desired.CPULimit)
+	compareQuantity("memory_limit", live.MemoryLimit, desired.MemoryLimit)
+	compareQuantity("cpu_request", live.CPURequest, desired.CPURequest)
+	compareQuantity("memory_request", live.MemoryRequest, desired.MemoryRequest)
+	compare("http_port", formatPort(live.HTTPPort), formatPort(desired.HTTPPort))
+	compare("tcp_port", formatPort(live.TCPPort), formatPort(desired.TCPPort))
+	compareQuantity("storage.size", live.PersistentVolumeSize, desired.PersistentVolumeSize)
+	compare("storage.path", live.PersistentVolumeMountPath, desired.PersistentVolumeMountPath)
+
+	changes = append(changes, DiffEnv(live.EnvironmentVars, desired.EnvironmentVars)...)
+	if desired.SecretVars != nil {
+		changes = append(changes, DiffSecrets(live.SecretKeys, desired.SecretVars)...)
+	}
+
+	return changes
+}
+
+// DiffEnv compares two sets of environment variables by key, with masked values
+func DiffEnv(live, desired map[string]string) []FieldChange {
+	keys := make(map[string]bool)
+	for key := range live {
+		keys[key] = true
+	}
+	for key := range desired {
+		keys[key] = true
+	}
+
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var changes []FieldChange
+	for _, key := range sortedKeys {
+		liveValue, inLive := live[key]
+		desiredValue, inDesired := desired[key]
+		field := "env." + key
+
+		switch {
+		case inLive && !inDesired:
+			changes = append(changes, FieldChange{Field: field, Type: ChangeRemoved, Live: MaskedValue})
+		case !inLive && inDesired:
+			changes = append(changes, FieldChange{Field: field, Type: ChangeAdded, Desired: MaskedValue})
+		case liveValue != desiredValue:
+			changes = append(changes, FieldChange{Field: field, Type: ChangeModified, Live: MaskedValue, Desired: MaskedValue})
+		}
+	}
+
+	return changes
+}
+
+// DiffSecrets compares the secret keys of a live deployment with desired secrets.
+// Secret values are never returned by the API, so only added and removed keys are reported.
+func DiffSecrets(liveKeys []string, desired map[string]string) []FieldChange {
+	live := make(map[string]bool, len(liveKeys))
+	for _, key := range liveKeys {
+		live[key] = true
+	}
+
+	var changes []FieldChange
+	for _, key := range liveKeys {
+		if _, ok := desired[key]; !ok {
+			changes = append(changes, FieldChange{Field: "secret." + key, Type: ChangeRemoved, Live: MaskedValue})
+		}
+	}
+	for key := range desired {
+		if !live[key] {
+			changes = append(changes, FieldChange{Field: "secret." + key, Type: ChangeAdded, Desired: MaskedValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes
+}
+
+// DiffEnvGroups compares the env groups of a live deployment with the desired groups.
+// Order matters, since later groups override earlier ones.
+func DiffEnvGroups(live, desired []string) []FieldChange {
+	if change, ok := diffValue("env_groups", strings.Join(live, ", "), strings.Join(desired, ", ")); ok {
+		return []FieldChange{change}
+	}
+	return nil
+}
+
+// diffValue returns the change between two plain values, if any
+func diffValue(field, live, desired string) (FieldChange, bool) {
+	switch {
+	case live == desired:
+		return FieldChange{}, false
+	case live == "":
+		return FieldChange{Field: field, Type: ChangeAdded, Desired: desired}, true
+	case desired == "":
+		return FieldChange{Field: field, Type: ChangeRemoved, Live: live}, true
+	default:
+		return FieldChange{Field: field, Type: ChangeModified, Live: live, Desired: desired}, true
+	}
+}
+
+func formatReplicas(replicas int, known bool) string {
+	if !known {
+		return ""
+	}
+	return strconv.Itoa(replicas)
+}
+
+func formatPort(port *int) string {
+	if port == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", *port)
+}
@@ -0,0 +1,401 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DotenvError reports a syntax error in an env file
+type DotenvError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *DotenvError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+// ParseDotenv parses env file contents following the de-facto dotenv format:
+//   - blank lines and lines starting with # are ignored, and an optional "export " prefix is allowed
+//   - unquoted values end at the end of the line or at a # preceded by whitespace,
+//     and surrounding whitespace is trimmed
+//   - 'single-quoted' and `backquoted` values are literal
+//   - "double-quoted" values support \n, \r, \t, \", \\ and \$ escapes
+//   - quoted values may span several lines
+//   - $VAR, ${VAR}, ${VAR:-default} and ${VAR-default} in unquoted and double-quoted values are
+//     expanded from keys defined earlier in the file, then from the local environment
+//
+// Warnings, such as duplicate keys or unset variables, are returned separately.
+func ParseDotenv(data []byte, filename string) (map[string]string, []string, error) {
+	return parseDotenv(data, filename, false)
+}
+
+// ParseDotenvLiteral parses env file contents like ParseDotenv, but keeps $ in values as
+// written instead of expanding variables
+func ParseDotenvLiteral(data []byte, filename string) (map[string]string, []string, error) {
+	return parseDotenv(data, filename, true)
+}
+
+func parseDotenv(data []byte, filename string, literal bool) (map[string]string, []string, error) {
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	content = strings.TrimPrefix(content, "\uFEFF")
+
+	p := &dotenvParser{
+		src:     content,
+		file:    filename,
+		line:    1,
+		col:     1,
+		literal: literal,
+		vars:    make(map[string]string),
+		defined: make(map[string]int),
+	}
+	if err := p.parse(); err != nil {
+		return nil, p.warnings, err
+	}
+
+	return p.vars, p.warnings, nil
+}
+
+// dotenvParser is a single-pass scanner that tracks line and column positions
+type dotenvParser struct {
+	src       string
+	pos       int
+	file      string
+	line, col int
+	literal   bool
+	vars      map[string]string
+	defined   map[string]int
+	warnings  []string
+}
+
+func (p *dotenvParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *dotenvParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *dotenvParser) advance() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+		p.col = 1
+	} else if c&0xC0 != 0x80 {
+		// Count characters, not UTF-8 continuation bytes
+		p.col++
+	}
+	return c
+}
+
+func (p *dotenvParser) errorf(line, col int, format string, args ...interface{}) error {
+	return &DotenvError{File: p.file, Line: line, Column: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *dotenvParser) warnf(line int, format string, args ...interface{}) {
+	p.warnings = append(p.warnings, fmt.Sprintf("%s:%d: %s", p.file, line, fmt.Sprintf(format, args...)))
+}
+
+func (p *dotenvParser) skipBlank() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.advance()
+	}
+}
+
+func (p *dotenvParser) skipLine() {
+	for !p.eof() && p.peek() != '\n' {
+		p.advance()
+	}
+}
+
+func (p *dotenvParser) parse() error {
+	for !p.eof() {
+		p.skipBlank()
+		switch p.peek() {
+		case 0:
+			return nil
+		case '\n':
+			p.advance()
+		case '#':
+			p.skipLine()
+		default:
+			if err := p.parseAssignment(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseAssignment parses one KEY=VALUE entry, including a trailing comment
+func (p *dotenvParser) parseAssignment() error {
+	line, col := p.line, p.col
+	key := p.readKey()
+	if key == "export" && (p.peek() == ' ' || p.peek() == '\t') {
+		p.skipBlank()
+		line, col = p.line, p.col
+		key = p.readKey()
+	}
+	if key == "" {
+		return p.errorf(p.line, p.col, "expected a variable name, found %q", p.peek())
+	}
+	if c := key[0]; c >= '0' && c <= '9' {
+		return p.errorf(line, col, "variable name %q cannot start with a digit", key)
+	}
+
+	p.skipBlank()
+	if p.peek() != '=' {
+		if p.eof() || p.peek() == '\n' {
+			return p.errorf(p.line, p.col, "missing '=' after %s (expected KEY=VALUE)", key)
+		}
+		return p.errorf(p.line, p.col, "unexpected %q after variable name %s (expected KEY=VALUE)", p.peek(), key)
+	}
+	p.advance()
+	p.skipBlank()
+
+	value, err := p.readValue()
+	if err != nil {
+		return err
+	}
+
+	// Only whitespace or a comment may follow the value
+	p.skipBlank()
+	switch p.peek() {
+	case 0, '\n':
+	case '#':
+		p.skipLine()
+	default:
+		return p.errorf(p.line, p.col, "unexpected %q after the value of %s", p.peek(), key)
+	}
+
+	if first, ok := p.defined[key]; ok {
+		p.warnf(line, "duplicate key %s (first defined at line %d), the last value is used", key, first)
+	} else {
+		p.defined[key] = line
+	}
+	p.vars[key] = value
+	return nil
+}
+
+func (p *dotenvParser) readKey() string {
+	start := p.pos
+	for !p.eof() && isEnvKeyChar(p.peek()) {
+		p.advance()
+	}
+	return p.src[start:p.pos]
+}
+
+func isEnvKeyChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (p *dotenvParser) readValue() (string, error) {
+	switch p.peek() {
+	case '\'', '`':
+		return p.readLiteral(p.peek())
+	case '"':
+		return p.readDoubleQuoted()
+	}
+	return p.readUnquoted()
+}
+
+// readLiteral reads a single-quoted or backquoted value without escapes or expansion
+func (p *dotenvParser) readLiteral(quote byte) (string, error) {
+	line, col := p.line, p.col
+	p.advance()
+	start := p.pos
+	for !p.eof() && p.peek() != quote {
+		p.advance()
+	}
+	if p.eof() {
+		return "", p.errorf(line, col, "unterminated %c-quoted value", quote)
+	}
+	value := p.src[start:p.pos]
+	p.advance()
+	return value, nil
+}
+
+func (p *dotenvParser) readDoubleQuoted() (string, error) {
+	line, col := p.line, p.col
+	p.advance()
+
+	var value strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf(line, col, "unterminated double-quoted value")
+		}
+		c := p.peek()
+		switch c {
+		case '"':
+			p.advance()
+			return value.String(), nil
+		case '\\':
+			p.advance()
+			if p.eof() {
+				continue
+			}
+			switch escaped := p.advance(); escaped {
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case '"', '\\', '$':
+				value.WriteByte(escaped)
+			default:
+				value.WriteByte('\\')
+				value.WriteByte(escaped)
+			}
+		case '$':
+			if p.literal {
+				value.WriteByte(p.advance())
+				continue
+			}
+			expanded, err := p.readExpansion()
+			if err != nil {
+				return "", err
+			}
+			value.WriteString(expanded)
+		default:
+			value.WriteByte(p.advance())
+		}
+	}
+}
+
+// readUnquoted reads a value up to the end of the line or an inline comment
+func (p *dotenvParser) readUnquoted() (string, error) {
+	var value strings.Builder
+	for !p.eof() && p.peek() != '\n' {
+		c := p.peek()
+		if c == '#' && (value.Len() == 0 || isBlank(p.src[p.pos-1])) {
+			break
+		}
+		switch c {
+		case '\\':
+			p.advance()
+			if p.peek() == '$' {
+				value.WriteByte(p.advance())
+			} else {
+				value.WriteByte('\\')
+			}
+		case '$':
+			if p.literal {
+				value.WriteByte(p.advance())
+				continue
+			}
+			expanded, err := p.readExpansion()
+			if err != nil {
+				return "", err
+			}
+			value.WriteString(expanded)
+		default:
+			value.WriteByte(p.advance())
+		}
+	}
+	return strings.TrimRight(value.String(), " \t"), nil
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// readExpansion expands $VAR, ${VAR}, ${VAR:-default} or ${VAR-default}; a lone $ is kept
+func (p *dotenvParser) readExpansion() (string, error) {
+	line, col := p.line, p.col
+	p.advance()
+
+	if p.peek() != '{' {
+		name := p.readVarName()
+		if name == "" {
+			return "$", nil
+		}
+		return p.lookup(name, line), nil
+	}
+
+	p.advance()
+	name := p.readVarName()
+	if name == "" {
+		return "", p.errorf(line, col, "invalid variable reference, expected ${NAME}")
+	}
+
+	var fallback *string
+	emptyIsUnset := false
+	if strings.HasPrefix(p.src[p.pos:], ":-") {
+		p.advance()
+		emptyIsUnset = true
+	}
+	if p.peek() == '-' {
+		p.advance()
+		start := p.pos
+		for !p.eof() && p.peek() != '}' && p.peek() != '\n' {
+			p.advance()
+		}
+		text := p.src[start:p.pos]
+		fallback = &text
+	}
+	if p.peek() != '}' {
+		return "", p.errorf(line, col, "unterminated variable reference ${%s", name)
+	}
+	p.advance()
+
+	if fallback != nil {
+		value, ok := p.resolve(name)
+		if !ok || (emptyIsUnset && value == "") {
+			return *fallback, nil
+		}
+		return value, nil
+	}
+	return p.lookup(name, line), nil
+}
+
+func (p *dotenvParser) readVarName() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (p.pos > start && c >= '0' && c <= '9') {
+			p.advance()
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+// resolve finds a variable among keys defined earlier in the file, then in the local environment
+func (p *dotenvParser) resolve(name string) (string, bool) {
+	if value, ok := p.vars[name]; ok {
+		return value, true
+	}
+	return os.LookupEnv(name)
+}
+
+func (p *dotenvParser) lookup(name string, line int) string {
+	value, ok := p.resolve(name)
+	if !ok {
+		p.warnf(line, "variable %s is not set, using an empty string", name)
+	}
+	return value
+}
+
+// DotenvQuote quotes a value for an env file so that ParseDotenv reads it back unchanged
+func DotenvQuote(value string) string {
+	if value != "" && strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:@=+,%", r))
+	}) < 0 {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\n") {
+		return "'" + value + "'"
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`)
+	return `"` + replacer.Replace(value) + `"`
+}
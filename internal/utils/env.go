@@ -1,94 +1,198 @@
 package utils
 
 import (
-	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+
+	"github.com/helmcode/coderun-cli/internal/client"
 )
 
-// ParseEnvFile parses a .env file and returns a map of environment variables
+// ParseEnvFile parses an environment file and returns a map of environment variables.
+// The format (dotenv, JSON or YAML) is detected automatically; see ReadEnvFile.
 func ParseEnvFile(filePath string) (map[string]string, error) {
-	envVars := make(map[string]string)
+	return ReadEnvFile(filePath, EnvFormatAuto)
+}
+
+// secretKeySuffixes and secretKeyFragments suggest that an environment variable holds a secret
+var secretKeySuffixes = []string{"_KEY", "_SECRET", "_TOKEN"}
+var secretKeyFragments = []string{"PASSWORD", "PASSWD", "SECRET", "PRIVATE", "CREDENTIAL"}
+
+// LooksLikeSecret reports whether an environment variable name suggests a secret value
+func LooksLikeSecret(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, suffix := range secretKeySuffixes {
+		if strings.HasSuffix(upper, suffix) {
+			return true
+		}
+	}
+	for _, fragment := range secretKeyFragments {
+		if strings.Contains(upper, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretLookingKeys returns the sorted keys of plain environment variables whose names suggest secrets
+func SecretLookingKeys(envVars map[string]string) []string {
+	var keys []string
+	for key := range envVars {
+		if LooksLikeSecret(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
 
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open env file '%s': %w", filePath, err)
+// EnvHash returns a short fingerprint of environment variables, so revisions can be compared
+// without showing values
+func EnvHash(envVars map[string]string) string {
+	if len(envVars) == 0 {
+		return "-"
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	lineNumber := 0
+	keys := make([]string, 0, len(envVars))
+	for key := range envVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-	for scanner.Scan() {
-		lineNumber++
-		line := strings.TrimSpace(scanner.Text())
+	hash := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s=%s\n", key, envVars[key])
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
 
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
+// EnvVarSource describes where a merged environment variable came from
+type EnvVarSource struct {
+	Key    string
+	Source string
+	// Overridden lists earlier sources whose value was replaced
+	Overridden []string
+}
 
-		// Parse KEY=VALUE format
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid format at line %d: %s (expected KEY=VALUE)", lineNumber, line)
-		}
+// MergeEnv loads env files in the given format in order, then applies overrides given as KEY=VALUE or KEY
+// with the named flag (e.g. --env). A bare KEY takes its value from the local environment. Later sources win.
+// It returns the merged variables and the source of each key, sorted by key.
+func MergeEnv(files []string, format string, overrides []string, flag string) (map[string]string, []EnvVarSource, error) {
+	envVars := make(map[string]string)
+	sources := make(map[string]*EnvVarSource)
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
+	set := func(key, value, source string) {
+		envVars[key] = value
+		if existing, ok := sources[key]; ok {
+			existing.Overridden = append(existing.Overridden, existing.Source)
+			existing.Source = source
+			return
+		}
+		sources[key] = &EnvVarSource{Key: key, Source: source}
+	}
 
-		// Remove quotes if present
-		if len(value) >= 2 {
-			if (strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`)) ||
-				(strings.HasPrefix(value, `'`) && strings.HasSuffix(value, `'`)) {
-				value = value[1 : len(value)-1]
+	stdinUsed := false
+	for _, file := range files {
+		if file == StdinEnvFile {
+			if stdinUsed {
+				return nil, nil, fmt.Errorf("stdin (-) can only be read once")
 			}
+			stdinUsed = true
+		}
+		fileVars, err := ReadEnvFile(file, format)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys := make([]string, 0, len(fileVars))
+		for key := range fileVars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			set(key, fileVars[key], envSourceName(file))
 		}
+	}
 
+	for _, override := range overrides {
+		key, value, hasValue := strings.Cut(override, "=")
+		key = strings.TrimSpace(key)
 		if key == "" {
-			return nil, fmt.Errorf("empty key at line %d", lineNumber)
+			return nil, nil, fmt.Errorf("invalid %s '%s' (expected KEY=VALUE or KEY)", flag, override)
 		}
-
-		envVars[key] = value
+		if hasValue {
+			set(key, value, flag)
+			continue
+		}
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s %s: %s is not set in the local environment", flag, key, key)
+		}
+		set(key, value, flag+" (local environment)")
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading env file: %w", err)
+	result := make([]EnvVarSource, 0, len(sources))
+	for _, source := range sources {
+		result = append(result, *source)
 	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+
+	return envVars, result, nil
+}
 
-	return envVars, nil
+// EffectiveVar is a variable of the environment a deployment actually runs with
+type EffectiveVar struct {
+	EnvVarSource
+	Value  string
+	Secret bool
 }
 
-// ValidateResourceValue validates CPU and memory resource values
-func ValidateResourceValue(value, resourceType string) error {
-	if value == "" {
-		return nil // Optional values
+// EffectiveEnv merges the env groups a deployment references, in order, with the deployment's
+// own variables, which win, and reports where each key comes from. Secret values are MaskedValue.
+func EffectiveEnv(deployment *client.DeploymentResponse, groups []*client.EnvGroup) []EffectiveVar {
+	vars := make(map[string]*EffectiveVar)
+	set := func(key, value, source string, secret bool) {
+		if secret {
+			value = MaskedValue
+		}
+		if existing, ok := vars[key]; ok {
+			existing.Overridden = append(existing.Overridden, existing.Source)
+			existing.Source, existing.Value, existing.Secret = source, value, secret
+			return
+		}
+		vars[key] = &EffectiveVar{EnvVarSource: EnvVarSource{Key: key, Source: source}, Value: value, Secret: secret}
 	}
 
-	// Basic validation for CPU and memory formats
-	switch resourceType {
-	case "cpu":
-		if !strings.HasSuffix(value, "m") && !isNumeric(value) {
-			return fmt.Errorf("invalid CPU format '%s' (examples: 100m, 0.5, 1)", value)
+	for _, group := range groups {
+		source := "group " + group.Name
+		for key, value := range group.Vars {
+			set(key, value, source, false)
 		}
-	case "memory":
-		if !strings.HasSuffix(value, "Mi") && !strings.HasSuffix(value, "Gi") && !strings.HasSuffix(value, "Ki") {
-			return fmt.Errorf("invalid memory format '%s' (examples: 128Mi, 1Gi, 512Ki)", value)
+		for _, key := range group.SecretKeys {
+			set(key, "", source, true)
 		}
 	}
+	for key, value := range deployment.EnvironmentVars {
+		set(key, value, "app", false)
+	}
+	for _, key := range deployment.SecretKeys {
+		set(key, "", "app", true)
+	}
+
+	result := make([]EffectiveVar, 0, len(vars))
+	for _, v := range vars {
+		result = append(result, *v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
 
-	return nil
+	return result
 }
 
-// isNumeric checks if a string represents a number
-func isNumeric(s string) bool {
-	for _, char := range s {
-		if char < '0' || char > '9' {
-			if char != '.' {
-				return false
-			}
-		}
+func envSourceName(file string) string {
+	if file == StdinEnvFile {
+		return "<stdin>"
 	}
-	return true
+	return file
 }
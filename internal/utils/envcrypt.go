@@ -0,0 +1,430 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"filippo.io/age"
+	"golang.org/x/crypto/hkdf"
+)
+
+// An encrypted env file keeps keys readable and encrypts each value, so that diffs show
+// which variables changed:
+//
+//	# coderun:encrypted v2 - edit with 'coderun env edit FILE'
+//	DB_PASSWORD=ENC[AES256_GCM,<base64 nonce and ciphertext>]
+//	# coderun:recipients age1...,age1...
+//	# coderun:datakey <base64 age file>
+//	# coderun:mac <hex>
+//
+// Each file has a random data key, encrypted with age for every recipient. Separate keys for
+// values and for the MAC are derived from it with HKDF. Values are encrypted using the variable
+// name as additional data so that values cannot be moved between keys, and the MAC over all
+// names and values detects added or removed lines.
+//
+// v1 files used the data key itself for both. They are still read, and are written back as v2.
+const (
+	encryptedEnvHeader     = "# coderun:encrypted v2 - edit with 'coderun env edit FILE'"
+	encryptedEnvMeta       = "# coderun:"
+	encryptedEnvVersion    = "encrypted"
+	encryptedValuePrefix   = "ENC[AES256_GCM,"
+	encryptedValueSuffix   = "]"
+	encryptedEnvRecipients = "recipients"
+	encryptedEnvDataKey    = "datakey"
+	encryptedEnvMAC        = "mac"
+)
+
+// ErrNoAgeIdentity is returned when an encrypted env file is read without an age identity
+var ErrNoAgeIdentity = errors.New("no age identity: set CODERUN_AGE_KEY or CODERUN_AGE_KEY_FILE, or create ~/.coderun/age.key")
+
+// EncryptedEnv is the decrypted contents of an encrypted env file, with the key material
+// needed to write it back with minimal changes
+type EncryptedEnv struct {
+	Values     map[string]string
+	Recipients []string
+
+	dataKey     []byte
+	legacy      bool
+	wrappedKey  string
+	wrappedFor  []string
+	ciphertexts map[string]string
+	plaintexts  map[string]string
+}
+
+// NewEncryptedEnv prepares values for encryption to the given age recipients
+func NewEncryptedEnv(values map[string]string, recipients []string) *EncryptedEnv {
+	return &EncryptedEnv{Values: values, Recipients: recipients}
+}
+
+// IsEncryptedEnv reports whether env file contents were written by EncryptedEnv.Marshal
+func IsEncryptedEnv(data []byte) bool {
+	return bytes.Contains(data, []byte("\n"+encryptedEnvMeta+encryptedEnvDataKey+" "))
+}
+
+// GetAgeKeyPath returns the default path of the age identity file
+func GetAgeKeyPath() (string, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "age.key"), nil
+}
+
+// LoadAgeIdentities reads age identities from CODERUN_AGE_KEY (the key itself),
+// CODERUN_AGE_KEY_FILE or ~/.coderun/age.key, in that order
+func LoadAgeIdentities() ([]age.Identity, error) {
+	if key := os.Getenv("CODERUN_AGE_KEY"); key != "" {
+		identities, err := age.ParseIdentities(strings.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CODERUN_AGE_KEY: %w", err)
+		}
+		return identities, nil
+	}
+
+	path := os.Getenv("CODERUN_AGE_KEY_FILE")
+	if path == "" {
+		defaultPath, err := GetAgeKeyPath()
+		if err != nil {
+			return nil, err
+		}
+		path = defaultPath
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoAgeIdentity
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read age key file: %w", err)
+	}
+	identities, err := age.ParseIdentities(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid age key file %s: %w", path, err)
+	}
+	return identities, nil
+}
+
+// AgeRecipientsOf returns the public keys of X25519 identities
+func AgeRecipientsOf(identities []age.Identity) []string {
+	var recipients []string
+	for _, identity := range identities {
+		if x25519, ok := identity.(*age.X25519Identity); ok {
+			recipients = append(recipients, x25519.Recipient().String())
+		}
+	}
+	return recipients
+}
+
+// DecryptEnv reads an encrypted env file, loading the identities with LoadAgeIdentities
+func DecryptEnv(data []byte, name string) (*EncryptedEnv, error) {
+	identities, err := LoadAgeIdentities()
+	if err != nil {
+		return nil, fmt.Errorf("%s is encrypted: %w", name, err)
+	}
+	return OpenEncryptedEnv(data, name, identities)
+}
+
+// OpenEncryptedEnv decrypts an encrypted env file with the given identities
+func OpenEncryptedEnv(data []byte, name string, identities []age.Identity) (*EncryptedEnv, error) {
+	env := &EncryptedEnv{
+		Values:      make(map[string]string),
+		ciphertexts: make(map[string]string),
+		plaintexts:  make(map[string]string),
+	}
+
+	var mac string
+	lineNo := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+
+		if meta, ok := strings.CutPrefix(line, encryptedEnvMeta); ok {
+			field, value, _ := strings.Cut(meta, " ")
+			switch field {
+			case encryptedEnvVersion:
+				env.legacy = strings.HasPrefix(value, "v1 ") || value == "v1"
+			case encryptedEnvRecipients:
+				env.Recipients = strings.Split(value, ",")
+			case encryptedEnvDataKey:
+				env.wrappedKey = value
+			case encryptedEnvMAC:
+				mac = value
+			}
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || key == "" || strings.IndexFunc(key, func(r rune) bool { return r > 127 || !isEnvKeyChar(byte(r)) }) >= 0 {
+			return nil, &DotenvError{File: name, Line: lineNo, Column: 1, Msg: "expected KEY=ENC[...]"}
+		}
+		if !strings.HasPrefix(value, encryptedValuePrefix) || !strings.HasSuffix(value, encryptedValueSuffix) {
+			return nil, &DotenvError{File: name, Line: lineNo, Column: len(key) + 2, Msg: fmt.Sprintf("value of %s is not encrypted; use 'coderun env edit' to change encrypted files", key)}
+		}
+		if _, dup := env.ciphertexts[key]; dup {
+			return nil, &DotenvError{File: name, Line: lineNo, Column: 1, Msg: fmt.Sprintf("duplicate key %s", key)}
+		}
+		env.ciphertexts[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if env.wrappedKey == "" || mac == "" {
+		return nil, fmt.Errorf("%s: missing coderun:%s or coderun:%s line", name, encryptedEnvDataKey, encryptedEnvMAC)
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(env.wrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid data key: %w", name, err)
+	}
+	reader, err := age.Decrypt(bytes.NewReader(wrapped), identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, fmt.Errorf("%s: none of your age identities is a recipient of this file", name)
+		}
+		return nil, fmt.Errorf("%s: failed to decrypt data key: %w", name, err)
+	}
+	var dataKey bytes.Buffer
+	if _, err := dataKey.ReadFrom(reader); err != nil {
+		return nil, fmt.Errorf("%s: failed to decrypt data key: %w", name, err)
+	}
+	if dataKey.Len() != 32 {
+		return nil, fmt.Errorf("%s: invalid data key", name)
+	}
+	env.dataKey = dataKey.Bytes()
+	env.wrappedFor = append([]string(nil), env.Recipients...)
+
+	keys, err := deriveEnvKeys(env.dataKey, env.legacy)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for key, ciphertext := range env.ciphertexts {
+		value, err := decryptEnvValue(keys.value, key, ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("%s: cannot decrypt %s: %w", name, key, err)
+		}
+		env.Values[key] = value
+		env.plaintexts[key] = value
+	}
+
+	if !hmac.Equal([]byte(mac), []byte(envMAC(keys.mac, env.Values))) {
+		return nil, fmt.Errorf("%s: MAC mismatch; lines were added, removed or changed without 'coderun env edit'", name)
+	}
+
+	return env, nil
+}
+
+// Marshal encrypts the values for the recipients. Values that did not change since the
+// file was opened keep their ciphertext, and the data key is kept unless a recipient was
+// removed, so that re-encrypting a file only changes the lines that changed. v1 files are
+// upgraded, which re-encrypts every value.
+func (e *EncryptedEnv) Marshal() ([]byte, error) {
+	if len(e.Recipients) == 0 {
+		return nil, fmt.Errorf("no age recipients")
+	}
+	recipients := make([]age.Recipient, 0, len(e.Recipients))
+	for _, r := range e.Recipients {
+		recipient, err := age.ParseX25519Recipient(strings.TrimSpace(r))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %q: %w", r, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	if e.dataKey == nil || removedRecipient(e.wrappedFor, e.Recipients) {
+		e.dataKey = make([]byte, 32)
+		if _, err := rand.Read(e.dataKey); err != nil {
+			return nil, fmt.Errorf("failed to generate data key: %w", err)
+		}
+		e.wrappedKey = ""
+		e.ciphertexts = nil
+	}
+	if e.legacy {
+		e.legacy = false
+		e.ciphertexts = nil
+	}
+	derived, err := deriveEnvKeys(e.dataKey, false)
+	if err != nil {
+		return nil, err
+	}
+	if e.wrappedKey == "" || !sameStrings(e.wrappedFor, e.Recipients) {
+		var wrapped bytes.Buffer
+		writer, err := age.Encrypt(&wrapped, recipients...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt data key: %w", err)
+		}
+		if _, err := writer.Write(e.dataKey); err != nil {
+			return nil, fmt.Errorf("failed to encrypt data key: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encrypt data key: %w", err)
+		}
+		e.wrappedKey = base64.StdEncoding.EncodeToString(wrapped.Bytes())
+		e.wrappedFor = append([]string(nil), e.Recipients...)
+	}
+
+	keys := make([]string, 0, len(e.Values))
+	for key := range e.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(encryptedEnvHeader + "\n")
+	ciphertexts := make(map[string]string, len(keys))
+	for _, key := range keys {
+		value := e.Values[key]
+		ciphertext, ok := e.ciphertexts[key]
+		if !ok || e.plaintexts[key] != value {
+			var err error
+			ciphertext, err = encryptEnvValue(derived.value, key, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		ciphertexts[key] = ciphertext
+		fmt.Fprintf(&b, "%s=%s\n", key, ciphertext)
+	}
+	fmt.Fprintf(&b, "%s%s %s\n", encryptedEnvMeta, encryptedEnvRecipients, strings.Join(e.Recipients, ","))
+	fmt.Fprintf(&b, "%s%s %s\n", encryptedEnvMeta, encryptedEnvDataKey, e.wrappedKey)
+	fmt.Fprintf(&b, "%s%s %s\n", encryptedEnvMeta, encryptedEnvMAC, envMAC(derived.mac, e.Values))
+
+	e.ciphertexts = ciphertexts
+	e.plaintexts = make(map[string]string, len(e.Values))
+	for key, value := range e.Values {
+		e.plaintexts[key] = value
+	}
+	return []byte(b.String()), nil
+}
+
+// envKeys are the keys derived from the data key of a file
+type envKeys struct {
+	value []byte
+	mac   []byte
+}
+
+// deriveEnvKeys derives the value and MAC keys from a data key; legacy v1 files use the data
+// key for both
+func deriveEnvKeys(dataKey []byte, legacy bool) (envKeys, error) {
+	if legacy {
+		return envKeys{value: dataKey, mac: dataKey}, nil
+	}
+	keys := envKeys{value: make([]byte, 32), mac: make([]byte, 32)}
+	if _, err := io.ReadFull(hkdf.New(sha256.New, dataKey, nil, []byte("coderun env values")), keys.value); err != nil {
+		return envKeys{}, fmt.Errorf("failed to derive value key: %w", err)
+	}
+	if _, err := io.ReadFull(hkdf.New(sha256.New, dataKey, nil, []byte("coderun env mac")), keys.mac); err != nil {
+		return envKeys{}, fmt.Errorf("failed to derive MAC key: %w", err)
+	}
+	return keys, nil
+}
+
+// encryptEnvValue encrypts one value, bound to its key name
+func encryptEnvValue(valueKey []byte, key, value string) (string, error) {
+	aead, err := newEnvAEAD(valueKey)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(key))
+	return encryptedValuePrefix + base64.StdEncoding.EncodeToString(sealed) + encryptedValueSuffix, nil
+}
+
+// decryptEnvValue decrypts one ENC[...] value, checking that it belongs to key
+func decryptEnvValue(valueKey []byte, key, ciphertext string) (string, error) {
+	encoded := strings.TrimSuffix(strings.TrimPrefix(ciphertext, encryptedValuePrefix), encryptedValueSuffix)
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid encoding: %w", err)
+	}
+
+	aead, err := newEnvAEAD(valueKey)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(key))
+	if err != nil {
+		return "", fmt.Errorf("authentication failed")
+	}
+	return string(plaintext), nil
+}
+
+func newEnvAEAD(valueKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(valueKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// envMAC authenticates the complete set of names and values
+func envMAC(macKey []byte, values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	mac := hmac.New(sha256.New, macKey)
+	for _, key := range keys {
+		mac.Write([]byte(key))
+		mac.Write([]byte{0})
+		mac.Write([]byte(values[key]))
+		mac.Write([]byte{0})
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// removedRecipient reports whether any of before is missing from after
+func removedRecipient(before, after []string) bool {
+	for _, r := range before {
+		found := false
+		for _, a := range after {
+			if strings.TrimSpace(a) == strings.TrimSpace(r) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return true
+		}
+	}
+	return false
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,253 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func newAgeIdentity(t *testing.T) *age.X25519Identity {
+	t.Helper()
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return identity
+}
+
+// encryptedLines returns the KEY=ENC[...] lines of an encrypted env file by key
+func encryptedLines(data []byte) map[string]string {
+	lines := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok && !strings.HasPrefix(line, "#") {
+			lines[key] = value
+		}
+	}
+	return lines
+}
+
+func TestEncryptedEnvRoundTrip(t *testing.T) {
+	alice := newAgeIdentity(t)
+	values := map[string]string{
+		"DB_PASSWORD": "pa$$word",
+		"EMPTY":       "",
+		"MULTILINE":   "line1\nline2",
+	}
+
+	data, err := NewEncryptedEnv(values, []string{alice.Recipient().String()}).Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !IsEncryptedEnv(data) {
+		t.Fatalf("IsEncryptedEnv = false for:\n%s", data)
+	}
+	if !strings.HasPrefix(string(data), encryptedEnvHeader+"\n") {
+		t.Fatalf("missing v2 header:\n%s", data)
+	}
+	if bytes.Contains(data, []byte("pa$$word")) || bytes.Contains(data, []byte("line1")) {
+		t.Fatalf("encrypted file contains plaintext:\n%s", data)
+	}
+
+	env, err := OpenEncryptedEnv(data, "prod.env", []age.Identity{alice})
+	if err != nil {
+		t.Fatalf("OpenEncryptedEnv: %v", err)
+	}
+	if !reflect.DeepEqual(env.Values, values) {
+		t.Fatalf("values = %q, want %q", env.Values, values)
+	}
+}
+
+func TestEncryptedEnvRejectsTampering(t *testing.T) {
+	alice := newAgeIdentity(t)
+	data, err := NewEncryptedEnv(map[string]string{"A": "1", "B": "2"}, []string{alice.Recipient().String()}).Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	lines := encryptedLines(data)
+
+	tests := []struct {
+		name    string
+		tamper  func(string) string
+		wantErr string
+	}{
+		{
+			name: "changed ciphertext",
+			tamper: func(s string) string {
+				sealed, _ := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(lines["A"], encryptedValuePrefix), encryptedValueSuffix))
+				sealed[len(sealed)-1] ^= 1
+				return strings.Replace(s, lines["A"], encryptedValuePrefix+base64.StdEncoding.EncodeToString(sealed)+encryptedValueSuffix, 1)
+			},
+			wantErr: "prod.env: cannot decrypt A: authentication failed",
+		},
+		{
+			name: "values swapped between keys",
+			tamper: func(s string) string {
+				s = strings.Replace(s, "A="+lines["A"], "A="+lines["B"], 1)
+				return strings.Replace(s, "B="+lines["B"], "B="+lines["A"], 1)
+			},
+			wantErr: "authentication failed",
+		},
+		{
+			name: "removed line",
+			tamper: func(s string) string {
+				return strings.Replace(s, "B="+lines["B"]+"\n", "", 1)
+			},
+			wantErr: "prod.env: MAC mismatch",
+		},
+		{
+			name: "changed MAC",
+			tamper: func(s string) string {
+				return strings.Replace(s, encryptedEnvMeta+encryptedEnvMAC+" ", encryptedEnvMeta+encryptedEnvMAC+" 00", 1)
+			},
+			wantErr: "prod.env: MAC mismatch",
+		},
+		{
+			name: "plaintext value added",
+			tamper: func(s string) string {
+				return strings.Replace(s, "A=", "C=plain\nA=", 1)
+			},
+			wantErr: "prod.env:2:3: value of C is not encrypted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := OpenEncryptedEnv([]byte(tt.tamper(string(data))), "prod.env", []age.Identity{alice})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncryptedEnvWrongIdentity(t *testing.T) {
+	alice, mallory := newAgeIdentity(t), newAgeIdentity(t)
+	data, err := NewEncryptedEnv(map[string]string{"A": "1"}, []string{alice.Recipient().String()}).Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	_, err = OpenEncryptedEnv(data, "prod.env", []age.Identity{mallory})
+	want := "prod.env: none of your age identities is a recipient of this file"
+	if err == nil || err.Error() != want {
+		t.Fatalf("error = %v, want %q", err, want)
+	}
+}
+
+func TestEncryptedEnvRecipientChange(t *testing.T) {
+	alice, bob := newAgeIdentity(t), newAgeIdentity(t)
+	values := map[string]string{"A": "1", "B": "2"}
+
+	data, err := NewEncryptedEnv(values, []string{alice.Recipient().String()}).Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	// Adding a recipient keeps the data key, so every value keeps its ciphertext
+	env, err := OpenEncryptedEnv(data, "prod.env", []age.Identity{alice})
+	if err != nil {
+		t.Fatalf("OpenEncryptedEnv: %v", err)
+	}
+	env.Recipients = []string{alice.Recipient().String(), bob.Recipient().String()}
+	added, err := env.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if before, after := encryptedLines(data), encryptedLines(added); before["A"] != after["A"] || before["B"] != after["B"] {
+		t.Errorf("adding a recipient re-encrypted values")
+	}
+	for _, identity := range []*age.X25519Identity{alice, bob} {
+		env, err := OpenEncryptedEnv(added, "prod.env", []age.Identity{identity})
+		if err != nil {
+			t.Fatalf("OpenEncryptedEnv: %v", err)
+		}
+		if !reflect.DeepEqual(env.Values, values) {
+			t.Fatalf("values = %q, want %q", env.Values, values)
+		}
+	}
+
+	// Removing a recipient rotates the data key; the values stay the same
+	env, err = OpenEncryptedEnv(added, "prod.env", []age.Identity{bob})
+	if err != nil {
+		t.Fatalf("OpenEncryptedEnv: %v", err)
+	}
+	env.Recipients = []string{bob.Recipient().String()}
+	removed, err := env.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if encryptedLines(added)["A"] == encryptedLines(removed)["A"] {
+		t.Errorf("removing a recipient kept the old ciphertext")
+	}
+	if _, err := OpenEncryptedEnv(removed, "prod.env", []age.Identity{alice}); err == nil {
+		t.Fatalf("a removed recipient can still decrypt the file")
+	}
+	env, err = OpenEncryptedEnv(removed, "prod.env", []age.Identity{bob})
+	if err != nil {
+		t.Fatalf("OpenEncryptedEnv: %v", err)
+	}
+	if !reflect.DeepEqual(env.Values, values) {
+		t.Fatalf("values = %q, want %q", env.Values, values)
+	}
+}
+
+func TestEncryptedEnvReadsV1(t *testing.T) {
+	alice := newAgeIdentity(t)
+	values := map[string]string{"A": "1", "B": "2"}
+
+	// v1 files use the data key itself for the values and the MAC
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		t.Fatal(err)
+	}
+	var wrapped bytes.Buffer
+	writer, err := age.Encrypt(&wrapped, alice.Recipient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Write(dataKey)
+	writer.Close()
+
+	var b strings.Builder
+	b.WriteString("# coderun:encrypted v1 - edit with 'coderun env edit FILE'\n")
+	for _, key := range []string{"A", "B"} {
+		ciphertext, err := encryptEnvValue(dataKey, key, values[key])
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", key, ciphertext)
+	}
+	fmt.Fprintf(&b, "# coderun:recipients %s\n", alice.Recipient())
+	fmt.Fprintf(&b, "# coderun:datakey %s\n", base64.StdEncoding.EncodeToString(wrapped.Bytes()))
+	fmt.Fprintf(&b, "# coderun:mac %s\n", envMAC(dataKey, values))
+
+	env, err := OpenEncryptedEnv([]byte(b.String()), "old.env", []age.Identity{alice})
+	if err != nil {
+		t.Fatalf("OpenEncryptedEnv: %v", err)
+	}
+	if !reflect.DeepEqual(env.Values, values) {
+		t.Fatalf("values = %q, want %q", env.Values, values)
+	}
+
+	// Writing it back upgrades it to v2
+	upgraded, err := env.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.HasPrefix(string(upgraded), encryptedEnvHeader+"\n") {
+		t.Fatalf("file was not upgraded:\n%s", upgraded)
+	}
+	env, err = OpenEncryptedEnv(upgraded, "old.env", []age.Identity{alice})
+	if err != nil {
+		t.Fatalf("OpenEncryptedEnv after upgrade: %v", err)
+	}
+	if !reflect.DeepEqual(env.Values, values) {
+		t.Fatalf("values = %q, want %q", env.Values, values)
+	}
+}
@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment file formats accepted by ReadEnvFile
+const (
+	EnvFormatAuto   = "auto"
+	EnvFormatDotenv = "dotenv"
+	EnvFormatJSON   = "json"
+	EnvFormatYAML   = "yaml"
+)
+
+// StdinEnvFile is the env file path that reads from standard input
+const StdinEnvFile = "-"
+
+// yamlKeyLine matches a first line such as "KEY: value", which identifies YAML input
+var yamlKeyLine = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*\s*:(\s|$)`)
+
+// ValidateEnvFormat checks an --env-format value
+func ValidateEnvFormat(format string) error {
+	switch format {
+	case EnvFormatAuto, EnvFormatDotenv, EnvFormatJSON, EnvFormatYAML:
+		return nil
+	}
+	return fmt.Errorf("invalid env format '%s' (expected auto, dotenv, json or yaml)", format)
+}
+
+// ReadEnvFile reads environment variables from a dotenv file, a JSON object or a flat YAML map
+// (including a Kubernetes ConfigMap), and decrypts files written by 'coderun env encrypt'.
+// The path "-" reads from stdin. With EnvFormatAuto the format is chosen from the file
+// extension, then from the content.
+func ReadEnvFile(path, format string) (map[string]string, error) {
+	return readEnvFile(path, format, false)
+}
+
+// ReadEnvFileLiteral is ReadEnvFile without $VAR expansion in dotenv files, for reading
+// values that are stored rather than deployed
+func ReadEnvFileLiteral(path, format string) (map[string]string, error) {
+	return readEnvFile(path, format, true)
+}
+
+func readEnvFile(path, format string, literal bool) (map[string]string, error) {
+	if err := ValidateEnvFormat(format); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	var err error
+	name := path
+	if path == StdinEnvFile {
+		name = "<stdin>"
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file '%s': %w", name, err)
+	}
+
+	if IsEncryptedEnv(data) {
+		env, err := DecryptEnv(data, name)
+		if err != nil {
+			return nil, err
+		}
+		return env.Values, nil
+	}
+
+	if format == EnvFormatAuto {
+		format = DetectEnvFormat(path, data)
+	}
+
+	switch format {
+	case EnvFormatJSON:
+		return parseEnvJSON(data, name)
+	case EnvFormatYAML:
+		return parseEnvYAML(data, name)
+	}
+
+	parse := ParseDotenv
+	if literal {
+		parse = ParseDotenvLiteral
+	}
+	envVars, warnings, err := parse(data, name)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return envVars, nil
+}
+
+// DetectEnvFormat guesses the format of env file contents from the file extension,
+// then from the first meaningful line
+func DetectEnvFormat(path string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return EnvFormatJSON
+	case ".yaml", ".yml":
+		return EnvFormatYAML
+	}
+
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\uFEFF")))
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return EnvFormatJSON
+	}
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "---" || (yamlKeyLine.MatchString(line) && !strings.Contains(strings.SplitN(line, ":", 2)[0], "=")) {
+			return EnvFormatYAML
+		}
+		break
+	}
+	return EnvFormatDotenv
+}
+
+// parseEnvJSON reads a JSON object whose values are all strings. Errors point at the offending
+// key or value, and a key defined twice is an error.
+func parseEnvJSON(data []byte, name string) (map[string]string, error) {
+	// A first pass reports syntax errors and checks that the document is an object
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			// The offset counts the offending byte, point at it rather than past it
+			line, col := offsetPosition(data, max(syntaxErr.Offset-1, 0))
+			return nil, fmt.Errorf("%s:%d:%d: invalid JSON: %v", name, line, col, err)
+		}
+		return nil, fmt.Errorf("%s: invalid JSON: %w", name, err)
+	}
+	if _, ok := raw.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("%s: expected a JSON object of \"KEY\": \"value\" pairs, got %s", name, jsonType(raw))
+	}
+
+	// The second pass walks the pairs in order, keeping track of their positions
+	decoder = json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("%s: invalid JSON: %w", name, err)
+	}
+
+	envVars := make(map[string]string)
+	firstLine := make(map[string]int)
+	for decoder.More() {
+		keyLine, keyCol := offsetPosition(data, nextJSONToken(data, decoder.InputOffset()))
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid JSON: %w", name, err)
+		}
+		key := token.(string)
+
+		valueLine, valueCol := offsetPosition(data, nextJSONToken(data, decoder.InputOffset()))
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("%s: invalid JSON: %w", name, err)
+		}
+
+		if line, exists := firstLine[key]; exists {
+			return nil, fmt.Errorf("%s:%d:%d: duplicate key %s (first defined at line %d)", name, keyLine, keyCol, key, line)
+		}
+		firstLine[key] = keyLine
+
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s:%d:%d: value of %s must be a string, got %s%s", name, valueLine, valueCol, key, jsonType(value), quoteHint(value))
+		}
+		envVars[key] = str
+	}
+	return envVars, nil
+}
+
+// nextJSONToken returns the offset of the next token at or after offset, skipping whitespace
+// and the separators the decoder has not consumed yet
+func nextJSONToken(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\n', '\r', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "a nested object (only flat KEY: value pairs are supported)"
+	case []interface{}:
+		return "an array (only flat KEY: value pairs are supported)"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case nil:
+		return "null"
+	}
+	return "a string"
+}
+
+func quoteHint(value interface{}) string {
+	switch v := value.(type) {
+	case json.Number:
+		return fmt.Sprintf(` (quote it: "%s")`, v)
+	case bool:
+		return fmt.Sprintf(` (quote it: "%t")`, v)
+	}
+	return ""
+}
+
+// offsetPosition converts a byte offset to a line and column
+func offsetPosition(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// parseEnvYAML reads a flat YAML map of strings, or the data of a Kubernetes ConfigMap
+func parseEnvYAML(data []byte, name string) (map[string]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: invalid YAML: %w", name, err)
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return map[string]string{}, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d:%d: expected a map of KEY: value pairs", name, root.Line, root.Column)
+	}
+	if configMapData := configMapData(root); configMapData != nil {
+		if configMapData.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s:%d:%d: data of the ConfigMap must be a map of KEY: value pairs", name, configMapData.Line, configMapData.Column)
+		}
+		root = configMapData
+	}
+
+	envVars := make(map[string]string, len(root.Content)/2)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		at := fmt.Sprintf("%s:%d:%d", name, value.Line, value.Column)
+
+		switch value.Kind {
+		case yaml.MappingNode:
+			return nil, fmt.Errorf("%s: value of %s is a nested map (only flat KEY: value pairs are supported)", at, key.Value)
+		case yaml.SequenceNode:
+			return nil, fmt.Errorf("%s: value of %s is a list (only flat KEY: value pairs are supported)", at, key.Value)
+		case yaml.AliasNode:
+			return nil, fmt.Errorf("%s: value of %s is an alias, which is not supported", at, key.Value)
+		}
+
+		switch value.Tag {
+		case "!!str":
+		case "!!null":
+			return nil, fmt.Errorf("%s: value of %s is empty (use \"\" for an empty string)", at, key.Value)
+		default:
+			return nil, fmt.Errorf("%s: value of %s must be a string, got %s (quote it: \"%s\")", at, key.Value, strings.TrimPrefix(value.Tag, "!!"), value.Value)
+		}
+
+		if _, exists := envVars[key.Value]; exists {
+			return nil, fmt.Errorf("%s:%d:%d: duplicate key %s", name, key.Line, key.Column, key.Value)
+		}
+		envVars[key.Value] = value.Value
+	}
+	return envVars, nil
+}
+
+// configMapData returns the data map of a Kubernetes ConfigMap, or nil for other documents
+func configMapData(root *yaml.Node) *yaml.Node {
+	var kind, data *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		switch root.Content[i].Value {
+		case "kind":
+			kind = root.Content[i+1]
+		case "data":
+			data = root.Content[i+1]
+		}
+	}
+	if kind == nil || kind.Value != "ConfigMap" {
+		return nil
+	}
+	if data == nil || (data.Kind == yaml.ScalarNode && data.Tag == "!!null") {
+		return &yaml.Node{Kind: yaml.MappingNode}
+	}
+	return data
+}
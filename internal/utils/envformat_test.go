@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEnvJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:  "flat object",
+			input: `{"A": "1", "B": "two words", "EMPTY": ""}`,
+			want:  map[string]string{"A": "1", "B": "two words", "EMPTY": ""},
+		},
+		{
+			name:    "duplicate key",
+			input:   "{\n  \"A\": \"1\",\n  \"B\": \"2\",\n  \"A\": \"3\"\n}",
+			wantErr: "test.json:4:3: duplicate key A (first defined at line 2)",
+		},
+		{
+			name:    "duplicate key on one line",
+			input:   `{"A":"1","A":"2"}`,
+			wantErr: "test.json:1:10: duplicate key A (first defined at line 1)",
+		},
+		{
+			name:    "number value",
+			input:   "{\n  \"PORT\": 8080\n}",
+			wantErr: `test.json:2:11: value of PORT must be a string, got a number (quote it: "8080")`,
+		},
+		{
+			name:    "nested object",
+			input:   `{"A": {"B": "c"}}`,
+			wantErr: "test.json:1:7: value of A must be a string, got a nested object (only flat KEY: value pairs are supported)",
+		},
+		{
+			name:    "not an object",
+			input:   `["A", "B"]`,
+			wantErr: `test.json: expected a JSON object of "KEY": "value" pairs, got an array (only flat KEY: value pairs are supported)`,
+		},
+		{
+			name:    "syntax error",
+			input:   "{\n  \"A\": \"1\"\n  \"B\": \"2\"\n}",
+			wantErr: "test.json:3:3: invalid JSON: invalid character '\"' after object key:value pair",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEnvJSON([]byte(tt.input), "test.json")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("vars = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEnvYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:  "flat map",
+			input: "A: \"1\"\nB: two words\n",
+			want:  map[string]string{"A": "1", "B": "two words"},
+		},
+		{
+			name:  "ConfigMap data",
+			input: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  A: \"1\"\n",
+			want:  map[string]string{"A": "1"},
+		},
+		{
+			name:  "ConfigMap without data",
+			input: "apiVersion: v1\nkind: ConfigMap\ndata:\n",
+			want:  map[string]string{},
+		},
+		{
+			name:    "ConfigMap with scalar data",
+			input:   "apiVersion: v1\nkind: ConfigMap\ndata: A=1\n",
+			wantErr: "test.yaml:3:7: data of the ConfigMap must be a map of KEY: value pairs",
+		},
+		{
+			name:    "ConfigMap with list data",
+			input:   "kind: ConfigMap\ndata:\n  - A\n",
+			wantErr: "test.yaml:3:3: data of the ConfigMap must be a map of KEY: value pairs",
+		},
+		{
+			name:    "duplicate key",
+			input:   "A: \"1\"\nA: \"2\"\n",
+			wantErr: "test.yaml:2:1: duplicate key A",
+		},
+		{
+			name:    "number value",
+			input:   "PORT: 8080\n",
+			wantErr: `test.yaml:1:7: value of PORT must be a string, got int (quote it: "8080")`,
+		},
+		{
+			name:    "not a map",
+			input:   "- A\n",
+			wantErr: "test.yaml:1:1: expected a map of KEY: value pairs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEnvYAML([]byte(tt.input), "test.yaml")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("vars = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
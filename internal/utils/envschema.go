@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultEnvSchemaFile is the env schema deploy uses when --env-schema is not given
+const DefaultEnvSchemaFile = ".env.schema"
+
+// Value types of env schema rules
+const (
+	EnvTypeString = "string"
+	EnvTypeInt    = "int"
+	EnvTypeBool   = "bool"
+	EnvTypeURL    = "url"
+	EnvTypeEnum   = "enum"
+)
+
+// EnvVarRule declares what a variable must look like
+type EnvVarRule struct {
+	Required    bool     `yaml:"required,omitempty"`
+	Type        string   `yaml:"type,omitempty"`
+	Values      []string `yaml:"values,omitempty"`
+	Pattern     string   `yaml:"pattern,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// EnvSchema maps variable names to their rules
+type EnvSchema map[string]*EnvVarRule
+
+// UnmarshalYAML decodes a mapping of variable names to rules; a bare "KEY:" is an empty rule
+func (s *EnvSchema) UnmarshalYAML(node *yaml.Node) error {
+	var rules map[string]*EnvVarRule
+	if err := node.Decode(&rules); err != nil {
+		return err
+	}
+	for key, rule := range rules {
+		if rule == nil {
+			rules[key] = &EnvVarRule{}
+		}
+	}
+	*s = rules
+	return nil
+}
+
+// Check validates the rule itself
+func (r *EnvVarRule) Check() error {
+	_, err := r.check()
+	return err
+}
+
+// check validates the rule and returns its compiled pattern, nil when it has none
+func (r *EnvVarRule) check() (*regexp.Regexp, error) {
+	switch r.Type {
+	case "", EnvTypeString, EnvTypeInt, EnvTypeBool, EnvTypeURL:
+		if len(r.Values) > 0 {
+			return nil, fmt.Errorf("values can only be used with type enum")
+		}
+	case EnvTypeEnum:
+		if len(r.Values) == 0 {
+			return nil, fmt.Errorf("type enum requires values")
+		}
+	default:
+		return nil, fmt.Errorf("unknown type %q (expected string, int, bool, url or enum)", r.Type)
+	}
+
+	if r.Pattern == "" {
+		return nil, nil
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+	// The pattern must match the whole value
+	return regexp.MustCompile("^(?:" + r.Pattern + ")$"), nil
+}
+
+// LoadEnvSchema reads an env schema file: a YAML mapping of variable names to rules
+//
+//	DATABASE_URL: {required: true, type: url}
+//	LOG_LEVEL: {type: enum, values: [debug, info, warn, error]}
+func LoadEnvSchema(path string) (EnvSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env schema: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	if len(root.Content) == 0 {
+		return EnvSchema{}, nil
+	}
+	mapping := root.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d:%d: expected a mapping of variable names to rules", path, mapping.Line, mapping.Column)
+	}
+
+	schema := make(EnvSchema)
+	var errs []error
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+
+		// A bare "KEY:" declares an optional variable of any value
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+			schema[key.Value] = &EnvVarRule{}
+			continue
+		}
+		if value.Kind != yaml.MappingNode {
+			errs = append(errs, fmt.Errorf("%s:%d:%d: %s: expected a mapping of rule fields", path, value.Line, value.Column, key.Value))
+			continue
+		}
+		if unknown := unknownRuleField(value); unknown != nil {
+			errs = append(errs, fmt.Errorf("%s:%d:%d: %s: unknown field %q", path, unknown.Line, unknown.Column, key.Value, unknown.Value))
+			continue
+		}
+		rule := &EnvVarRule{}
+		if err := value.Decode(rule); err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d:%d: %s: %s", path, value.Line, value.Column, key.Value, yamlTypeErrorMessage(err)))
+			continue
+		}
+		pattern, err := rule.check()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d:%d: %s: %v", path, value.Line, value.Column, key.Value, err))
+			continue
+		}
+		rule.pattern = pattern
+		schema[key.Value] = rule
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return schema, nil
+}
+
+// yamlTypeErrorMessage returns the messages of a yaml decoding error without their line prefixes
+func yamlTypeErrorMessage(err error) string {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return strings.TrimPrefix(err.Error(), "yaml: ")
+	}
+
+	messages := make([]string, 0, len(typeErr.Errors))
+	for _, msg := range typeErr.Errors {
+		if _, rest, ok := strings.Cut(msg, ": "); ok && strings.HasPrefix(msg, "line ") {
+			msg = rest
+		}
+		messages = append(messages, msg)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// unknownRuleField returns the first key of a rule mapping that is not an EnvVarRule field
+func unknownRuleField(node *yaml.Node) *yaml.Node {
+	for i := 0; i < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case "required", "type", "values", "pattern", "description":
+		default:
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// Validate checks variables against the schema and reports every problem at once.
+// Messages name variables but never include their values, which may be secrets.
+// Variables for which deferred returns true (e.g. unresolved secret references, or secrets
+// kept on the server) are only checked for presence; deferred may be nil.
+func (s EnvSchema) Validate(envVars map[string]string, deferred func(key, value string) bool) error {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		rule := s[key]
+		if rule == nil {
+			rule = &EnvVarRule{}
+		}
+		value, ok := envVars[key]
+		if !ok || value == "" {
+			if rule.Required && !ok {
+				errs = append(errs, fmt.Errorf("%s: required but not set", key))
+			} else if rule.Required {
+				errs = append(errs, fmt.Errorf("%s: required but empty", key))
+			}
+			continue
+		}
+		if deferred != nil && deferred(key, value) {
+			continue
+		}
+		if err := rule.validateValue(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateValue checks a non-empty value against the rule's type and pattern
+func (r *EnvVarRule) validateValue(value string) error {
+	switch r.Type {
+	case EnvTypeInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("expected an integer")
+		}
+	case EnvTypeBool:
+		switch strings.ToLower(value) {
+		case "true", "false", "1", "0", "yes", "no", "on", "off":
+		default:
+			return fmt.Errorf("expected a boolean (true, false, 1, 0, yes, no, on or off)")
+		}
+	case EnvTypeURL:
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.Scheme == "" || (parsed.Host == "" && parsed.Opaque == "") {
+			return fmt.Errorf("expected a URL with a scheme and host")
+		}
+	case EnvTypeEnum:
+		found := false
+		for _, allowed := range r.Values {
+			if value == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("must be one of %s", strings.Join(r.Values, ", "))
+		}
+	}
+
+	if r.Pattern != "" {
+		// Rules from LoadEnvSchema come compiled; others are compiled here, without caching
+		pattern := r.pattern
+		if pattern == nil {
+			var err error
+			if pattern, err = r.check(); err != nil {
+				return err
+			}
+		}
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("does not match pattern %s", r.Pattern)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+)
+
+// redactedPrefix marks a plain environment value stored in the journal only as a hash,
+// because its name looks like a secret (see LooksLikeSecret)
+const redactedPrefix = "redacted:sha256:"
+
+// JournalEntry records a deployment spec applied by the CLI.
+// Entries include environment values, except those that look like secrets, which are stored
+// as hashes; journal files are only readable by the owner.
+type JournalEntry struct {
+	client.Revision
+	DeploymentID string `json:"deployment_id"`
+	Action       string `json:"action"`
+}
+
+// GetJournalPath returns the path of the local journal of an app
+func GetJournalPath(appName string) (string, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "journal", appName+".jsonl"), nil
+}
+
+// ReadJournal returns the journal entries of a deployment of an app, oldest first.
+// Entries of an earlier deployment with the same app name are skipped, so a recreated app
+// starts a new history. An app without a journal has no entries.
+func ReadJournal(appName, deploymentID string) ([]JournalEntry, error) {
+	journalPath, err := GetJournalPath(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(journalPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid journal entry at %s:%d: %w", journalPath, lineNum, err)
+		}
+		if entry.DeploymentID != deploymentID {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	return entries, nil
+}
+
+// AppendJournal records a deployed spec as the next revision of the app
+func AppendJournal(deployment *client.DeploymentResponse, action string) (*JournalEntry, error) {
+	entries, err := ReadJournal(deployment.AppName, deployment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := deployment.Spec()
+	envHash := EnvHash(spec.EnvironmentVars)
+	spec.EnvironmentVars = redactEnv(spec.EnvironmentVars)
+	entry := JournalEntry{
+		Revision: client.Revision{
+			Revision:  1,
+			Spec:      *spec,
+			EnvHash:   envHash,
+			Author:    currentUsername(),
+			CreatedAt: time.Now().UTC(),
+		},
+		DeploymentID: deployment.ID,
+		Action:       action,
+	}
+	if len(entries) > 0 {
+		entry.Revision.Revision = entries[len(entries)-1].Revision.Revision + 1
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+
+	journalPath, err := GetJournalPath(deployment.AppName)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(journalPath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	file, err := os.OpenFile(journalPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// redactEnv returns a copy of envVars in which values of secret-looking keys are replaced by a hash
+func redactEnv(envVars map[string]string) map[string]string {
+	if envVars == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(envVars))
+	for key, value := range envVars {
+		if LooksLikeSecret(key) {
+			value = redactedValue(value)
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// redactedValue returns the placeholder stored in the journal for a secret-looking value
+func redactedValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return redactedPrefix + hex.EncodeToString(sum[:])
+}
+
+// RestoreRedacted puts back the redacted values of a journal spec from the live environment,
+// when the live value is the one that was hashed. It returns the sorted keys that could not be
+// restored because their live value changed or was removed.
+func RestoreRedacted(spec *client.DeploymentCreate, liveEnv map[string]string) []string {
+	var missing []string
+	for key, value := range spec.EnvironmentVars {
+		if !strings.HasPrefix(value, redactedPrefix) {
+			continue
+		}
+		if live, ok := liveEnv[key]; ok && redactedValue(live) == value {
+			spec.EnvironmentVars[key] = live
+			continue
+		}
+		missing = append(missing, key)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// currentUsername returns the local user name recorded as the author of journal entries
+func currentUsername() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+)
+
+func TestJournal(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	deployment := func(id, image string, env map[string]string) *client.DeploymentResponse {
+		return &client.DeploymentResponse{ID: id, AppName: "web-app", Image: image, Replicas: 1, EnvironmentVars: env}
+	}
+
+	steps := []*client.DeploymentResponse{
+		deployment("first", "web:1", map[string]string{"LOG_LEVEL": "info", "DB_PASSWORD": "hunter2"}),
+		deployment("first", "web:2", map[string]string{"LOG_LEVEL": "debug", "DB_PASSWORD": "hunter2"}),
+		// The app is deleted and created again under the same name
+		deployment("second", "web:3", map[string]string{"API_TOKEN": "tok-123"}),
+	}
+	for _, d := range steps {
+		if _, err := AppendJournal(d, "created"); err != nil {
+			t.Fatalf("AppendJournal: %v", err)
+		}
+	}
+
+	first, err := ReadJournal("web-app", "first")
+	if err != nil {
+		t.Fatalf("ReadJournal: %v", err)
+	}
+	second, err := ReadJournal("web-app", "second")
+	if err != nil {
+		t.Fatalf("ReadJournal: %v", err)
+	}
+	if len(first) != 2 || first[1].Revision.Revision != 2 {
+		t.Fatalf("first deployment: %d entries, want revisions 1 and 2", len(first))
+	}
+	if len(second) != 1 || second[0].Revision.Revision != 1 || second[0].Spec.Image != "web:3" {
+		t.Fatalf("recreated deployment inherited history: %+v", second)
+	}
+
+	// Secret-looking values never reach the journal file, plain ones do
+	path, err := GetJournalPath("web-app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") || strings.Contains(string(data), "tok-123") {
+		t.Fatalf("journal contains secret values:\n%s", data)
+	}
+	if !strings.Contains(string(data), `"LOG_LEVEL":"debug"`) {
+		t.Fatalf("journal lost plain values:\n%s", data)
+	}
+	if first[0].EnvHash != EnvHash(steps[0].EnvironmentVars) {
+		t.Errorf("env hash = %s, want the hash of the real values", first[0].EnvHash)
+	}
+}
+
+func TestRestoreRedacted(t *testing.T) {
+	spec := func() *client.DeploymentCreate {
+		return &client.DeploymentCreate{EnvironmentVars: redactEnv(map[string]string{
+			"LOG_LEVEL":   "info",
+			"DB_PASSWORD": "hunter2",
+			"API_TOKEN":   "tok-123",
+		})}
+	}
+
+	tests := []struct {
+		name        string
+		live        map[string]string
+		wantMissing []string
+	}{
+		{
+			name: "unchanged values are restored",
+			live: map[string]string{"LOG_LEVEL": "debug", "DB_PASSWORD": "hunter2", "API_TOKEN": "tok-123"},
+		},
+		{
+			name:        "changed values cannot be restored",
+			live:        map[string]string{"DB_PASSWORD": "rotated", "API_TOKEN": "tok-123"},
+			wantMissing: []string{"DB_PASSWORD"},
+		},
+		{
+			name:        "removed values cannot be restored",
+			live:        map[string]string{},
+			wantMissing: []string{"API_TOKEN", "DB_PASSWORD"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := spec()
+			missing := RestoreRedacted(s, tt.live)
+			if !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Fatalf("missing = %v, want %v", missing, tt.wantMissing)
+			}
+			if s.EnvironmentVars["LOG_LEVEL"] != "info" {
+				t.Errorf("plain value changed: %q", s.EnvironmentVars["LOG_LEVEL"])
+			}
+			if len(missing) == 0 && (s.EnvironmentVars["DB_PASSWORD"] != "hunter2" || s.EnvironmentVars["API_TOKEN"] != "tok-123") {
+				t.Errorf("values not restored: %v", s.EnvironmentVars)
+			}
+		})
+	}
+}
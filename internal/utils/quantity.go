@@ -0,0 +1,280 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxQuantityExponent bounds decimal exponents such as 1e9, keeping values reasonably sized
+const maxQuantityExponent = 64
+
+// binarySuffixes maps binary suffixes to their power of two, largest first
+var binarySuffixes = []struct {
+	suffix string
+	power  uint
+}{
+	{"Ei", 60}, {"Pi", 50}, {"Ti", 40}, {"Gi", 30}, {"Mi", 20}, {"Ki", 10},
+}
+
+// decimalSuffixes maps decimal suffixes to their power of ten, largest first
+var decimalSuffixes = []struct {
+	suffix string
+	power  int
+}{
+	{"E", 18}, {"P", 15}, {"T", 12}, {"G", 9}, {"M", 6}, {"k", 3}, {"", 0}, {"m", -3}, {"u", -6}, {"n", -9},
+}
+
+// Quantity is a Kubernetes resource quantity such as 500m, 1.5, 128Mi, 1G or 1e9.
+// The value is kept exactly, so 1Gi and 1024Mi compare equal.
+type Quantity struct {
+	value  *big.Rat
+	binary bool
+}
+
+// ParseQuantity parses a quantity: an optionally signed decimal number followed by a binary
+// suffix (Ki, Mi, Gi, Ti, Pi, Ei), a decimal suffix (n, u, m, k, M, G, T, P, E) or a decimal
+// exponent (e3, E-2)
+func ParseQuantity(s string) (Quantity, error) {
+	if s == "" {
+		return Quantity{}, fmt.Errorf("empty quantity")
+	}
+
+	rest := s
+	negative := false
+	switch rest[0] {
+	case '+':
+		rest = rest[1:]
+	case '-':
+		negative = true
+		rest = rest[1:]
+	}
+
+	// The number: digits with an optional fraction, at least one digit overall
+	whole := leadingDigits(rest)
+	rest = rest[len(whole):]
+	fraction := ""
+	if strings.HasPrefix(rest, ".") {
+		fraction = leadingDigits(rest[1:])
+		rest = rest[1+len(fraction):]
+	}
+	if whole == "" && fraction == "" {
+		return Quantity{}, fmt.Errorf("missing number")
+	}
+
+	mantissa, _ := new(big.Int).SetString(whole+fraction, 10)
+	value := new(big.Rat).SetFrac(mantissa, pow10(len(fraction)))
+
+	q := Quantity{value: value}
+	if power, ok := binarySuffix(rest); ok {
+		value.Mul(value, new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), power)))
+		q.binary = true
+	} else if power, ok := decimalSuffix(rest); ok {
+		scaleByPow10(value, power)
+	} else if rest[0] == 'e' || rest[0] == 'E' {
+		exponent, err := strconv.Atoi(rest[1:])
+		if err != nil {
+			return Quantity{}, fmt.Errorf("invalid suffix %q", rest)
+		}
+		if exponent > maxQuantityExponent || exponent < -maxQuantityExponent {
+			return Quantity{}, fmt.Errorf("exponent %d out of range", exponent)
+		}
+		scaleByPow10(value, exponent)
+	} else {
+		return Quantity{}, fmt.Errorf("invalid suffix %q", rest)
+	}
+
+	if negative {
+		value.Neg(value)
+	}
+	return q, nil
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the quantity
+func (q Quantity) Sign() int {
+	return q.rat().Sign()
+}
+
+// Cmp compares two quantities by value, returning -1, 0 or +1
+func (q Quantity) Cmp(other Quantity) int {
+	return q.rat().Cmp(other.rat())
+}
+
+// IsWhole reports whether the quantity is a whole number of units (e.g. bytes)
+func (q Quantity) IsWhole() bool {
+	return q.rat().IsInt()
+}
+
+// IsMilliPrecise reports whether the quantity is a whole number of thousandths (e.g. millicores)
+func (q Quantity) IsMilliPrecise() bool {
+	return new(big.Rat).Mul(q.rat(), big.NewRat(1000, 1)).IsInt()
+}
+
+// String returns the canonical form of the quantity: the largest suffix that keeps the number
+// whole, binary if the quantity was written with a binary suffix (1024Mi is 1Gi, 1.5 is 1500m,
+// 1e9 is 1G)
+func (q Quantity) String() string {
+	value := q.rat()
+	if value.Sign() == 0 {
+		return "0"
+	}
+
+	sign := ""
+	if value.Sign() < 0 {
+		sign = "-"
+		value = new(big.Rat).Neg(value)
+	}
+
+	if q.binary && value.IsInt() {
+		n := value.Num()
+		for _, s := range binarySuffixes {
+			if n.TrailingZeroBits() >= s.power {
+				return sign + new(big.Int).Rsh(n, s.power).String() + s.suffix
+			}
+		}
+		return sign + n.String()
+	}
+
+	for _, s := range decimalSuffixes {
+		scaled := new(big.Rat).Set(value)
+		scaleByPow10(scaled, -s.power)
+		if scaled.IsInt() {
+			return sign + scaled.Num().String() + s.suffix
+		}
+	}
+
+	// Finer than a nano unit: round up to the next nano unit, as Kubernetes does
+	scaled := new(big.Rat).Set(value)
+	scaleByPow10(scaled, 9)
+	nanos, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if remainder.Sign() != 0 {
+		nanos.Add(nanos, big.NewInt(1))
+	}
+	return sign + nanos.String() + "n"
+}
+
+// rat returns the value of the quantity, treating the zero Quantity as 0
+func (q Quantity) rat() *big.Rat {
+	if q.value == nil {
+		return new(big.Rat)
+	}
+	return q.value
+}
+
+// EqualQuantities reports whether two strings are the same quantity written differently,
+// such as 1Gi and 1024Mi. Unparsable strings are only equal if they are identical.
+func EqualQuantities(a, b string) bool {
+	if a == b {
+		return true
+	}
+	qa, errA := ParseQuantity(a)
+	qb, errB := ParseQuantity(b)
+	return errA == nil && errB == nil && qa.Cmp(qb) == 0
+}
+
+// resourceLabels and resourceExamples describe each resource type in error messages
+var resourceLabels = map[string]string{"cpu": "CPU", "memory": "memory", "storage": "storage"}
+var resourceExamples = map[string]string{
+	"cpu":     "100m, 0.5, 1",
+	"memory":  "128Mi, 1Gi, 512M",
+	"storage": "1Gi, 500Mi, 10G",
+}
+
+// ParseResourceValue parses a CPU, memory or storage value. Values must be greater than zero;
+// CPU may not be finer than a millicore and memory and storage must be whole bytes.
+func ParseResourceValue(value, resourceType string) (Quantity, error) {
+	label := resourceLabels[resourceType]
+	q, err := ParseQuantity(value)
+	if err != nil {
+		return Quantity{}, fmt.Errorf("invalid %s format '%s': %v (examples: %s)", label, value, err, resourceExamples[resourceType])
+	}
+	if q.Sign() <= 0 {
+		return Quantity{}, fmt.Errorf("%s value '%s' must be greater than zero", label, value)
+	}
+
+	switch resourceType {
+	case "cpu":
+		if !q.IsMilliPrecise() {
+			return Quantity{}, fmt.Errorf("CPU value '%s' is finer than 1m (one millicore)", value)
+		}
+	case "memory", "storage":
+		if !q.IsWhole() {
+			return Quantity{}, fmt.Errorf("%s value '%s' is not a whole number of bytes", label, value)
+		}
+	}
+
+	return q, nil
+}
+
+// ValidateResourceValue validates CPU, memory and storage resource values
+func ValidateResourceValue(value, resourceType string) error {
+	if value == "" {
+		return nil // Optional values
+	}
+	_, err := ParseResourceValue(value, resourceType)
+	return err
+}
+
+// ValidateResourceRange checks that a resource request does not exceed its limit.
+// Either value may be empty; both are expected to have been validated already.
+func ValidateResourceRange(limit, request, resourceType string) error {
+	if limit == "" || request == "" {
+		return nil
+	}
+	limitQuantity, err := ParseResourceValue(limit, resourceType)
+	if err != nil {
+		return err
+	}
+	requestQuantity, err := ParseResourceValue(request, resourceType)
+	if err != nil {
+		return err
+	}
+	if limitQuantity.Cmp(requestQuantity) < 0 {
+		return fmt.Errorf("%s limit %s is below the %s request %s", resourceLabels[resourceType], limit, resourceLabels[resourceType], request)
+	}
+	return nil
+}
+
+// leadingDigits returns the run of ASCII digits at the start of s
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
+
+// binarySuffix returns the power of two of a binary suffix
+func binarySuffix(s string) (uint, bool) {
+	for _, b := range binarySuffixes {
+		if s == b.suffix {
+			return b.power, true
+		}
+	}
+	return 0, false
+}
+
+// decimalSuffix returns the power of ten of a decimal suffix; no suffix means 10^0
+func decimalSuffix(s string) (int, bool) {
+	for _, d := range decimalSuffixes {
+		if s == d.suffix {
+			return d.power, true
+		}
+	}
+	return 0, false
+}
+
+// pow10 returns 10^n for n >= 0
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// scaleByPow10 multiplies value by 10^power in place
+func scaleByPow10(value *big.Rat, power int) {
+	if power >= 0 {
+		value.Mul(value, new(big.Rat).SetInt(pow10(power)))
+	} else {
+		value.Quo(value, new(big.Rat).SetInt(pow10(-power)))
+	}
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string // canonical form
+		wantErr string
+	}{
+		// Invalid
+		{input: "", wantErr: "empty quantity"},
+		{input: "m", wantErr: "missing number"},
+		{input: ".", wantErr: "missing number"},
+		{input: "-", wantErr: "missing number"},
+		{input: "1.2.3", wantErr: `invalid suffix ".3"`},
+		{input: "100x", wantErr: `invalid suffix "x"`},
+		{input: "abcMi", wantErr: "missing number"},
+		{input: "1e", wantErr: `invalid suffix "e"`},
+		{input: "1e+", wantErr: `invalid suffix "e+"`},
+		{input: "1e999", wantErr: "exponent 999 out of range"},
+		{input: "1mi", wantErr: `invalid suffix "mi"`},
+		{input: "1 Gi", wantErr: `invalid suffix " Gi"`},
+		{input: "1KiB", wantErr: `invalid suffix "KiB"`},
+
+		// Valid, normalized to the largest suffix that keeps the number whole
+		{input: "1G", want: "1G"},
+		{input: "512M", want: "512M"},
+		{input: "1e9", want: "1G"},
+		{input: "1E9", want: "1G"},
+		{input: "1e+3", want: "1k"},
+		{input: "15e-1", want: "1500m"},
+		{input: "0.5", want: "500m"},
+		{input: ".5", want: "500m"},
+		{input: "1.", want: "1"},
+		{input: "100m", want: "100m"},
+		{input: "1000m", want: "1"},
+		{input: "1.5", want: "1500m"},
+		{input: "1024Mi", want: "1Gi"},
+		{input: "0.5Gi", want: "512Mi"},
+		{input: "1536Ki", want: "1536Ki"},
+		{input: "1.5Ki", want: "1536"},
+		{input: "1E", want: "1E"},
+		{input: "2000000k", want: "2G"},
+		{input: "+1", want: "1"},
+		{input: "-1.5", want: "-1500m"},
+		{input: "0", want: "0"},
+		{input: "0Gi", want: "0"},
+		{input: "1n", want: "1n"},
+		{input: "1e-10", want: "1n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			q, err := ParseQuantity(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseQuantity: %v", err)
+			}
+			if got := q.String(); got != tt.want {
+				t.Fatalf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqualQuantities(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1Gi", "1024Mi", true},
+		{"1", "1000m", true},
+		{"0.5", "500m", true},
+		{"1e9", "1G", true},
+		{"1G", "1Gi", false},
+		{"500m", "501m", false},
+		{"", "", true},
+		{"", "1", false},
+		{"abc", "abc", true},
+		{"abc", "abd", false},
+	}
+
+	for _, tt := range tests {
+		if got := EqualQuantities(tt.a, tt.b); got != tt.want {
+			t.Errorf("EqualQuantities(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseResourceValue(t *testing.T) {
+	tests := []struct {
+		value        string
+		resourceType string
+		wantErr      string // substring; empty means valid
+	}{
+		{"500m", "cpu", ""},
+		{"0.5", "cpu", ""},
+		{"2", "cpu", ""},
+		{"1.5", "cpu", ""},
+		{"0.001", "cpu", ""},
+		{"0.0001", "cpu", "finer than 1m"},
+		{"1500u", "cpu", "finer than 1m"},
+		{"m", "cpu", "invalid CPU format 'm'"},
+		{"1.2.3", "cpu", "invalid CPU format '1.2.3'"},
+		{".", "cpu", "invalid CPU format '.'"},
+		{"100x", "cpu", "invalid CPU format '100x'"},
+		{"0", "cpu", "must be greater than zero"},
+		{"-1", "cpu", "must be greater than zero"},
+
+		{"128Mi", "memory", ""},
+		{"1G", "memory", ""},
+		{"512M", "memory", ""},
+		{"1e9", "memory", ""},
+		{"0.5Gi", "memory", ""},
+		{"abcMi", "memory", "invalid memory format 'abcMi'"},
+		{"1.5", "memory", "not a whole number of bytes"},
+		{"100m", "memory", "not a whole number of bytes"},
+		{"0.1Ki", "memory", "not a whole number of bytes"},
+
+		{"10Gi", "storage", ""},
+		{"500Mi", "storage", ""},
+		{"1T", "storage", ""},
+		{"Gi", "storage", "invalid storage format 'Gi'"},
+		{"0Gi", "storage", "must be greater than zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resourceType+"/"+tt.value, func(t *testing.T) {
+			_, err := ParseResourceValue(tt.value, tt.resourceType)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := ValidateResourceValue("", "cpu"); err != nil {
+		t.Errorf("empty values are optional, got %v", err)
+	}
+}
+
+func TestValidateResourceRange(t *testing.T) {
+	tests := []struct {
+		limit, request, resourceType string
+		wantErr                      string
+	}{
+		{"1", "500m", "cpu", ""},
+		{"500m", "0.5", "cpu", ""},
+		{"1Gi", "1024Mi", "memory", ""},
+		{"1Gi", "1G", "memory", ""},
+		{"500m", "1", "cpu", "CPU limit 500m is below the CPU request 1"},
+		{"1G", "1Gi", "memory", "memory limit 1G is below the memory request 1Gi"},
+		{"256Mi", "0.5Gi", "memory", "memory limit 256Mi is below the memory request 0.5Gi"},
+		{"", "2Gi", "memory", ""},
+		{"1Gi", "", "memory", ""},
+		{"1Gi", "abc", "memory", "invalid memory format 'abc'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.limit+"/"+tt.request, func(t *testing.T) {
+			err := ValidateResourceRange(tt.limit, tt.request, tt.resourceType)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
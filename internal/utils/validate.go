@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/helmcode/coderun-cli/internal/client"
+)
+
+// appNamePattern matches lowercase letters, numbers and hyphens
+var appNamePattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// ValidateAppName checks the app name rules enforced by the platform
+func ValidateAppName(name string) error {
+	if name == "" {
+		return fmt.Errorf("app name is required (3-30 chars, lowercase letters/numbers/hyphens only)")
+	}
+	if len(name) < 3 {
+		return fmt.Errorf("app name must be at least 3 characters long")
+	}
+	if len(name) > 30 {
+		return fmt.Errorf("app name must be no more than 30 characters long")
+	}
+	// Validate format: only lowercase letters, numbers, and hyphens
+	if !appNamePattern.MatchString(name) {
+		return fmt.Errorf("app name must contain only lowercase letters, numbers, and hyphens")
+	}
+	// Cannot start or end with hyphen
+	if strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-") {
+		return fmt.Errorf("app name cannot start or end with a hyphen")
+	}
+	return nil
+}
+
+// ValidatePort checks that a port is in the valid range
+func ValidatePort(port int, portType string) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("%s port must be between 1 and 65535", portType)
+	}
+	return nil
+}
+
+// ValidateStorage checks that storage size and mount path are given together and well formed
+func ValidateStorage(size, mountPath string) error {
+	if size == "" && mountPath == "" {
+		return nil
+	}
+
+	// Both values must be provided together
+	if size == "" || mountPath == "" {
+		return fmt.Errorf("when using persistent storage, both size and path are required")
+	}
+
+	// Validate storage size format
+	if err := ValidateResourceValue(size, "storage"); err != nil {
+		return err
+	}
+
+	// Validate mount path format (must be absolute path)
+	if !strings.HasPrefix(mountPath, "/") {
+		return fmt.Errorf("storage path must be an absolute path starting with '/' (e.g., '/data', '/var/lib/mysql')")
+	}
+
+	return nil
+}
+
+// ValidateDeploymentSpec runs every local check on a deployment request.
+// The image is not checked because it may come from a build that has not run yet.
+func ValidateDeploymentSpec(spec *client.DeploymentCreate) error {
+	if err := ValidateAppName(spec.AppName); err != nil {
+		return err
+	}
+
+	if spec.Replicas < 0 {
+		return fmt.Errorf("replicas cannot be negative")
+	}
+
+	// Validate resource values
+	if err := ValidateResourceValue(spec.CPULimit, "cpu"); err != nil {
+		return err
+	}
+	if err := ValidateResourceValue(spec.CPURequest, "cpu"); err != nil {
+		return err
+	}
+	if err := ValidateResourceValue(spec.MemoryLimit, "memory"); err != nil {
+		return err
+	}
+	if err := ValidateResourceValue(spec.MemoryRequest, "memory"); err != nil {
+		return err
+	}
+	if err := ValidateResourceRange(spec.CPULimit, spec.CPURequest, "cpu"); err != nil {
+		return err
+	}
+	if err := ValidateResourceRange(spec.MemoryLimit, spec.MemoryRequest, "memory"); err != nil {
+		return err
+	}
+
+	// Validate that only one of HTTP or TCP port is specified
+	if spec.HTTPPort != nil && spec.TCPPort != nil {
+		return fmt.Errorf("cannot specify both an HTTP port and a TCP port")
+	}
+	if spec.HTTPPort != nil {
+		if err := ValidatePort(*spec.HTTPPort, "HTTP"); err != nil {
+			return err
+		}
+	}
+	if spec.TCPPort != nil {
+		if err := ValidatePort(*spec.TCPPort, "TCP"); err != nil {
+			return err
+		}
+	}
+
+	return ValidateStorage(spec.PersistentVolumeSize, spec.PersistentVolumeMountPath)
+}
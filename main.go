@@ -6,6 +6,8 @@ import (
 	"github.com/helmcode/coderun-cli/cmd"
 )
 
+//go:generate go run . schema -o schema/coderun.schema.json
+
 // Build version injected at compile time
 var version = "dev"
 